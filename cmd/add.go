@@ -6,31 +6,68 @@ import (
 	"github.com/vkunssec/husky/internal/tools"
 )
 
+var (
+	name      string
+	appendCmd bool
+	noInstall bool
+)
+
 var addCmd = &cobra.Command{
-	Use:     "add [hook] [comando]",
-	Short:   "Add a hook",
-	Long:    "Adiciona um novo hook ao repositório",
-	Args:    cobra.ExactArgs(2),
-	Example: "husky add pre-commit 'go test ./...'",
-	Run: func(cmd *cobra.Command, args []string) {
-		hook := args[0]
-		cmdStr := args[1]
+	Use:   "add [hook] [command]",
+	Short: "Add a hook",
+	Long: `Add a command to a hook of the repository and install the hooks.
+
+An existing hook is only replaced after confirmation, which requires a terminal:
+use --force to replace it or --append to add the command to it in scripts and CI.
+
+With --name the command is a named step of the hook, added after the existing
+steps or replacing the step with the same name.
 
-		if err := lib.Add(hook, cmdStr); err != nil {
+With --template the hooks of a template are added instead of a command; a script
+template runs in its own hook unless another one is given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if template != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	Example: "husky add pre-commit 'go test ./...'\nhusky add pre-commit --name vet 'go vet ./...'\nhusky add pre-commit 'go vet ./...' --append --no-install\nhusky add --template conventional",
+	Run: func(cmd *cobra.Command, args []string) {
+		if template != "" {
+			hook := ""
+			if len(args) > 0 {
+				hook = args[0]
+			}
+			if err := lib.AddTemplate(template, hook, force); err != nil {
+				tools.LogError("❌ Error adding template: %v\n", err)
+				return
+			}
+		} else if err := lib.Add(args[0], args[1], lib.AddOptions{Name: name, Force: force, Append: appendCmd}); err != nil {
 			tools.LogError("❌ Error adding hook: %v\n", err)
 			return
 		}
 
-		if err := lib.Install(lib.InstallOptions{Quiet: quiet}); err != nil {
-			tools.LogError("❌ Error installing hooks: %v\n", err)
-			return
+		if !noInstall {
+			if err := lib.Install(lib.InstallOptions{Quiet: quiet}); err != nil {
+				tools.LogError("❌ Error installing hooks: %v\n", err)
+				return
+			}
 		}
 
-		tools.LogInfo("✅ Hook '%s' added successfully!\n", hook)
+		if template != "" {
+			tools.LogInfo("✅ Template '%s' added successfully!\n", template)
+		} else {
+			tools.LogInfo("✅ Hook '%s' added successfully!\n", args[0])
+		}
 	},
 }
 
 func init() {
 	addCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	addCmd.Flags().StringVarP(&name, "name", "n", "", "Name of the step")
+	addCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite an existing hook without asking")
+	addCmd.Flags().BoolVarP(&appendCmd, "append", "a", false, "Add the command to an existing hook")
+	addCmd.Flags().StringVarP(&template, "template", "t", "", "Add the hooks of a template")
+	addCmd.Flags().BoolVar(&noInstall, "no-install", false, "Do not install the hooks afterwards")
 	rootCmd.AddCommand(addCmd)
 }
@@ -12,7 +12,7 @@ import (
 )
 
 // Define types for functions
-type AddFunc func(hook, cmdStr string) error
+type AddFunc func(hook, cmdStr string, opts lib.AddOptions) error
 type InstallFunc func(opts lib.InstallOptions) error
 
 // Declare original variables with explicit types
@@ -33,7 +33,7 @@ func TestAddCmd(t *testing.T) {
 	prevInstall := lib.Install
 
 	// Mock mais detalhado para Add
-	lib.Add = func(hook, cmdStr string) error {
+	lib.Add = func(hook, cmdStr string, opts lib.AddOptions) error {
 		// valid hooks
 		validHooks := map[string]bool{
 			"pre-commit":         true,
@@ -108,13 +108,13 @@ func TestAddCmd(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// create a new command instance for each test
 			cmd := &cobra.Command{
-				Use:  "add [hook] [comando]",
+				Use:  "add [hook] [command]",
 				Args: cobra.ExactArgs(2),
 				RunE: func(cmd *cobra.Command, args []string) error {
 					hook := args[0]
 					cmdStr := args[1]
 
-					if err := lib.Add(hook, cmdStr); err != nil {
+					if err := lib.Add(hook, cmdStr, lib.AddOptions{}); err != nil {
 						return err
 					}
 
@@ -163,6 +163,9 @@ func TestAddCmd(t *testing.T) {
 func TestAddCmdFlags(t *testing.T) {
 	// check if the quiet flag was registered correctly
 	assert.NotNil(t, addCmd.Flags().Lookup("quiet"))
+	assert.NotNil(t, addCmd.Flags().Lookup("force"))
+	assert.NotNil(t, addCmd.Flags().Lookup("append"))
+	assert.NotNil(t, addCmd.Flags().Lookup("no-install"))
 
 	// check default value of the quiet flag
 	quietFlag, err := addCmd.Flags().GetBool("quiet")
@@ -170,13 +173,45 @@ func TestAddCmdFlags(t *testing.T) {
 	assert.False(t, quietFlag)
 }
 
+func TestAddCmdOptions(t *testing.T) {
+	prevAdd := lib.Add
+	prevInstall := lib.Install
+	defer func() {
+		lib.Add = prevAdd
+		lib.Install = prevInstall
+		force, appendCmd, noInstall = false, false, false
+	}()
+
+	var got lib.AddOptions
+	installed := false
+	lib.Add = func(hook, cmdStr string, opts lib.AddOptions) error {
+		got = opts
+		return nil
+	}
+	lib.Install = func(opts lib.InstallOptions) error {
+		installed = true
+		return nil
+	}
+
+	assert.NoError(t, addCmd.Flags().Parse([]string{"--append", "--no-install"}))
+	addCmd.Run(addCmd, []string{"pre-commit", "go vet ./..."})
+	assert.Equal(t, lib.AddOptions{Append: true}, got)
+	assert.False(t, installed)
+
+	appendCmd, noInstall = false, false
+	assert.NoError(t, addCmd.Flags().Parse([]string{"--force"}))
+	addCmd.Run(addCmd, []string{"pre-commit", "go vet ./..."})
+	assert.Equal(t, lib.AddOptions{Force: true}, got)
+	assert.True(t, installed)
+}
+
 func TestAddCmdValidation(t *testing.T) {
 	// save original functions
 	prevAdd := lib.Add
 	prevInstall := lib.Install
 
 	// configure mocks
-	lib.Add = func(hook, cmdStr string) error {
+	lib.Add = func(hook, cmdStr string, opts lib.AddOptions) error {
 		// t.Logf("validating - hook: '%s', command: '%s'", hook, cmdStr)
 
 		// basic validations
@@ -273,13 +308,13 @@ func TestAddCmdValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// create a new command instance for each test
 			cmd := &cobra.Command{
-				Use:  "add [hook] [comando]",
+				Use:  "add [hook] [command]",
 				Args: cobra.ExactArgs(2),
 				RunE: func(cmd *cobra.Command, args []string) error {
 					hook := args[0]
 					cmdStr := args[1]
 
-					if err := lib.Add(hook, cmdStr); err != nil {
+					if err := lib.Add(hook, cmdStr, lib.AddOptions{}); err != nil {
 						return err
 					}
 
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var checkCommitMsgCmd = &cobra.Command{
+	Use:   "check-commit-msg [file]",
+	Short: "Check a commit message",
+	Long: `Check a commit message file against the commit_msg rules of the config:
+Conventional Commits header with allowed types and scopes, header length, body
+wrapping, required trailers such as Signed-off-by and ticket IDs.
+
+Each problem is reported with its line and column. In the config, the same check
+is available as a step with builtin: commit-msg.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "husky check-commit-msg .git/COMMIT_EDITMSG\nhusky add commit-msg 'husky check-commit-msg \"$1\"'",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lib.CheckCommitMsg(args[0], cmd.ErrOrStderr()); err != nil {
+			tools.LogError("❌ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCommitMsgCmd)
+}
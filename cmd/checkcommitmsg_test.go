@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestCheckCommitMsgCmd(t *testing.T) {
+	prevCheck := lib.CheckCommitMsg
+	defer func() { lib.CheckCommitMsg = prevCheck }()
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "check-commit-msg [file]", checkCommitMsgCmd.Use)
+		assert.Error(t, checkCommitMsgCmd.Args(checkCommitMsgCmd, []string{}))
+		assert.NoError(t, checkCommitMsgCmd.Args(checkCommitMsgCmd, []string{".git/COMMIT_EDITMSG"}))
+	})
+
+	t.Run("should check the given file", func(t *testing.T) {
+		var got string
+		lib.CheckCommitMsg = func(file string, w io.Writer) error {
+			got = file
+			return nil
+		}
+
+		checkCommitMsgCmd.Run(checkCommitMsgCmd, []string{".git/COMMIT_EDITMSG"})
+		assert.Equal(t, ".git/COMMIT_EDITMSG", got)
+	})
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var disableCmd = &cobra.Command{
+	Use:   "disable [hook]",
+	Short: "Disable a hook",
+	Long: `Disable a hook, keeping its definition. The setting is saved in the config,
+so the hook does nothing in every clone of the repository until it is enabled again`,
+	Args:    cobra.ExactArgs(1),
+	Example: "husky disable pre-push",
+	Run: func(cmd *cobra.Command, args []string) {
+		setHookState(args[0], lib.Disable, "disabled")
+	},
+}
+
+var enableCmd = &cobra.Command{
+	Use:     "enable [hook]",
+	Short:   "Enable a hook",
+	Long:    "Enable a hook disabled with 'husky disable'",
+	Args:    cobra.ExactArgs(1),
+	Example: "husky enable pre-push",
+	Run: func(cmd *cobra.Command, args []string) {
+		setHookState(args[0], lib.Enable, "enabled")
+	},
+}
+
+// setHookState enables or disables a hook and reinstalls the hooks
+func setHookState(hook string, set func(string) error, state string) {
+	if err := set(hook); err != nil {
+		tools.LogError("❌ Error updating hook: %v\n", err)
+		return
+	}
+
+	if err := lib.Install(lib.InstallOptions{Quiet: quiet}); err != nil {
+		tools.LogError("❌ Error installing hooks: %v\n", err)
+		return
+	}
+
+	tools.LogInfo("✅ Hook '%s' %s successfully!\n", hook, state)
+}
+
+func init() {
+	disableCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	enableCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	rootCmd.AddCommand(disableCmd)
+	rootCmd.AddCommand(enableCmd)
+}
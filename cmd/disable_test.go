@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestHookStateCmds(t *testing.T) {
+	prevRemove, prevDisable, prevEnable, prevInstall := lib.Remove, lib.Disable, lib.Enable, lib.Install
+	defer func() {
+		lib.Remove, lib.Disable, lib.Enable, lib.Install = prevRemove, prevDisable, prevEnable, prevInstall
+	}()
+
+	calls := []string{}
+	record := func(name string) func(string) error {
+		return func(hook string) error {
+			calls = append(calls, name+" "+hook)
+			if hook == "invalid-hook" {
+				return errors.New("invalid hook")
+			}
+			return nil
+		}
+	}
+	lib.Disable, lib.Enable = record("disable"), record("enable")
+	lib.Remove = func(hook string, opts lib.RemoveOptions) error {
+		return record("remove")(hook)
+	}
+	lib.Install = func(opts lib.InstallOptions) error {
+		calls = append(calls, "install")
+		return nil
+	}
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "remove [hook]", removeCmd.Use)
+		assert.Equal(t, "disable [hook]", disableCmd.Use)
+		assert.Equal(t, "enable [hook]", enableCmd.Use)
+		assert.Error(t, removeCmd.Args(removeCmd, []string{}))
+		assert.Error(t, disableCmd.Args(disableCmd, []string{"pre-commit", "extra"}))
+	})
+
+	t.Run("should update the hook and reinstall", func(t *testing.T) {
+		calls = nil
+		removeCmd.Run(removeCmd, []string{"pre-push"})
+		disableCmd.Run(disableCmd, []string{"pre-commit"})
+		enableCmd.Run(enableCmd, []string{"pre-commit"})
+		assert.Equal(t, []string{"remove pre-push", "install", "disable pre-commit", "install", "enable pre-commit", "install"}, calls)
+	})
+
+	t.Run("should not reinstall on error", func(t *testing.T) {
+		calls = nil
+		disableCmd.Run(disableCmd, []string{"invalid-hook"})
+		assert.Equal(t, []string{"disable invalid-hook"}, calls)
+	})
+}
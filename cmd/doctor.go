@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var fix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose hook problems",
+	Long: `Check everything that commonly prevents hooks from running: the git binary
+and its version, the repository, core.hooksPath overrides, hooks that are not
+executable, have CRLF line endings, no shebang or a missing interpreter, installed
+hooks that drifted from their source and leftovers of previous runs.
+
+Each problem comes with a severity and a suggested fix, --fix applies the safe ones.`,
+	Example: "husky doctor --fix",
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := lib.Doctor(lib.DoctorOptions{Fix: fix, Stdout: cmd.OutOrStdout()})
+		if err != nil {
+			tools.LogError("❌ Error running doctor: %v\n", err)
+			os.Exit(1)
+		}
+		if report.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&fix, "fix", false, "Apply the safe fixes")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctorCmd(t *testing.T) {
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "doctor", doctorCmd.Use)
+		assert.Equal(t, "Diagnose hook problems", doctorCmd.Short)
+		assert.NotNil(t, doctorCmd.Flags().Lookup("fix"))
+	})
+
+	t.Run("should be registered in root command", func(t *testing.T) {
+		cmd, _, err := rootCmd.Find([]string{"doctor"})
+		assert.NoError(t, err)
+		assert.Equal(t, doctorCmd, cmd)
+	})
+}
@@ -7,8 +7,9 @@ import (
 )
 
 var (
-	quiet bool
-	force bool
+	quiet    bool
+	force    bool
+	template string
 )
 
 var initCmd = &cobra.Command{
@@ -18,17 +19,25 @@ var initCmd = &cobra.Command{
 	
 This command will:
 - Configure the basic hook structure
-- Prepare the git environment`,
+- Prepare the git environment
+
+With --template the hooks of a template are declared in the config,
+see husky templates list`,
+	Example: "husky init --template go",
 	Run: func(cmd *cobra.Command, args []string) {
 		if !quiet {
 			tools.LogInfo("Initializing Husky...")
 		}
 
 		opts := lib.InitOptions{
-			Config:    lib.NewDefaultConfig(),
-			Templates: lib.LoadTemplates(),
-			Force:     force,
-			Quiet:     quiet,
+			Config:   lib.NewDefaultConfig(),
+			Template: template,
+			Force:    force,
+			Quiet:    quiet,
+		}
+		// loading the templates may fetch the template sources, only do it when one is used
+		if template != "" {
+			opts.Templates = lib.LoadTemplates()
 		}
 
 		optsInstall := lib.InstallOptions{
@@ -54,5 +63,6 @@ This command will:
 func init() {
 	initCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
 	initCmd.Flags().BoolVarP(&force, "force", "f", false, "Force initialization")
+	initCmd.Flags().StringVarP(&template, "template", "t", "", "Template declaring the initial hooks")
 	rootCmd.AddCommand(initCmd)
 }
@@ -6,6 +6,11 @@ import (
 	"github.com/vkunssec/husky/internal/tools"
 )
 
+var (
+	chain    bool
+	strategy string
+)
+
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install husky",
@@ -14,10 +19,21 @@ var installCmd = &cobra.Command{
 This command will:
 - Check prerequisites
 - Install the configured hooks
-- Configure the git scripts`,
+- Configure the git scripts
+
+Hooks of other tools found in the git hooks directory are never
+overwritten silently: use --chain to run them before the husky
+hooks or --force to replace them. Both back them up first.
+
+Hooks are hard linked by default. Use --strategy (or install_strategy
+in the config) to symlink or copy them, or to set core.hooksPath to
+the husky hooks directory instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		opts := lib.InstallOptions{
-			Quiet: quiet,
+			Quiet:    quiet,
+			Force:    force,
+			Chain:    chain,
+			Strategy: strategy,
 		}
 
 		if err := lib.Install(opts); err != nil {
@@ -33,5 +49,8 @@ This command will:
 
 func init() {
 	installCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	installCmd.Flags().BoolVarP(&force, "force", "f", false, "Replace hooks not managed by husky")
+	installCmd.Flags().BoolVar(&chain, "chain", false, "Run hooks not managed by husky before the husky ones")
+	installCmd.Flags().StringVarP(&strategy, "strategy", "s", "", "Install strategy: link, symlink, copy or hooks-path")
 	rootCmd.AddCommand(installCmd)
 }
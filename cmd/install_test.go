@@ -95,6 +95,21 @@ func TestInstallInit(t *testing.T) {
 		assert.Equal(t, "false", flag.DefValue)
 	})
 
+	t.Run("should register conflict flags", func(t *testing.T) {
+		for _, name := range []string{"force", "chain"} {
+			flag := installCmd.Flags().Lookup(name)
+			assert.NotNil(t, flag)
+			assert.Equal(t, "false", flag.DefValue)
+		}
+	})
+
+	t.Run("should register strategy flag", func(t *testing.T) {
+		flag := installCmd.Flags().Lookup("strategy")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "s", flag.Shorthand)
+		assert.Equal(t, "", flag.DefValue)
+	})
+
 	t.Run("should be registered in root command", func(t *testing.T) {
 		cmd, _, err := rootCmd.Find([]string{"install"})
 		assert.NoError(t, err)
@@ -3,17 +3,39 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var (
+	installed bool
+	steps     bool
+	jsonOut   bool
 )
 
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [hook]",
 	Short: "List all hooks",
-	Long:  "List all hooks implemented in the repository",
+	Long: `List the hooks supported by git, or with --installed the state of the hooks
+of the repository: defined in .husky, installed in the git hooks directory, in sync
+with husky, executable, disabled, or foreign (not managed by husky).
+
+With --steps, or a hook, list the steps of the hooks declared in the config`,
+	Args:    cobra.MaximumNArgs(1),
+	Example: "husky list --installed --json\nhusky list pre-commit",
 	Run: func(cmd *cobra.Command, args []string) {
-		lib.List()
+		opts := lib.ListOptions{Installed: installed, Steps: steps, JSON: jsonOut, Stdout: cmd.OutOrStdout()}
+		if len(args) > 0 {
+			opts.Hook = args[0]
+		}
+		if err := lib.List(opts); err != nil {
+			tools.LogError("❌ Error listing hooks: %v\n", err)
+		}
 	},
 }
 
 func init() {
+	listCmd.Flags().BoolVarP(&installed, "installed", "i", false, "Show the state of the hooks of the repository")
+	listCmd.Flags().BoolVarP(&steps, "steps", "s", false, "List the steps of the hooks")
+	listCmd.Flags().BoolVar(&jsonOut, "json", false, "Print JSON")
 	rootCmd.AddCommand(listCmd)
 }
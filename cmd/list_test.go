@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestListCmd(t *testing.T) {
+	prevList := lib.List
+	defer func() { lib.List = prevList }()
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "list [hook]", listCmd.Use)
+		assert.Equal(t, "List all hooks", listCmd.Short)
+		assert.NotNil(t, listCmd.Flags().Lookup("installed"))
+		assert.NotNil(t, listCmd.Flags().Lookup("json"))
+	})
+
+	t.Run("should pass flags to list", func(t *testing.T) {
+		var got lib.ListOptions
+		lib.List = func(opts lib.ListOptions) error {
+			got = opts
+			return nil
+		}
+
+		assert.NoError(t, listCmd.Flags().Parse([]string{"--installed", "--json"}))
+		listCmd.Run(listCmd, nil)
+		assert.True(t, got.Installed)
+		assert.True(t, got.JSON)
+
+		installed, jsonOut = false, false
+	})
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var step string
+
+var removeCmd = &cobra.Command{
+	Use:   "remove [hook]",
+	Short: "Remove a hook",
+	Long: `Remove a hook from the config, the .husky directory and the git hooks directory,
+or only one of its steps with --step (by name or position)`,
+	Args:    cobra.ExactArgs(1),
+	Example: "husky remove pre-push\nhusky remove pre-commit --step lint",
+	Run: func(cmd *cobra.Command, args []string) {
+		hook := args[0]
+
+		if err := lib.Remove(hook, lib.RemoveOptions{Step: step}); err != nil {
+			tools.LogError("❌ Error removing hook: %v\n", err)
+			return
+		}
+
+		if err := lib.Install(lib.InstallOptions{Quiet: quiet}); err != nil {
+			tools.LogError("❌ Error installing hooks: %v\n", err)
+			return
+		}
+
+		if step != "" {
+			tools.LogInfo("✅ Step '%s' removed from hook '%s' successfully!\n", step, hook)
+			return
+		}
+		tools.LogInfo("✅ Hook '%s' removed successfully!\n", hook)
+	},
+}
+
+func init() {
+	removeCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	removeCmd.Flags().StringVar(&step, "step", "", "Only remove this step of the hook")
+	rootCmd.AddCommand(removeCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var reorderCmd = &cobra.Command{
+	Use:   "reorder [hook] [step...]",
+	Short: "Reorder the steps of a hook",
+	Long: `Move the given steps (by name or position) first, in the given order.
+The other steps keep their order after them`,
+	Args:    cobra.MinimumNArgs(2),
+	Example: "husky reorder pre-commit lint vet",
+	Run: func(cmd *cobra.Command, args []string) {
+		hook := args[0]
+
+		if err := lib.Reorder(hook, args[1:]); err != nil {
+			tools.LogError("❌ Error reordering steps: %v\n", err)
+			return
+		}
+
+		tools.LogInfo("✅ Steps of hook '%s' reordered successfully!\n", hook)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reorderCmd)
+}
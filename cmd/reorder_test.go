@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestReorderCmd(t *testing.T) {
+	prevReorder := lib.Reorder
+	defer func() { lib.Reorder = prevReorder }()
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "reorder [hook] [step...]", reorderCmd.Use)
+		assert.Error(t, reorderCmd.Args(reorderCmd, []string{"pre-commit"}))
+		assert.NoError(t, reorderCmd.Args(reorderCmd, []string{"pre-commit", "lint", "test"}))
+	})
+
+	t.Run("should pass the steps to reorder", func(t *testing.T) {
+		var gotHook string
+		var gotSteps []string
+		lib.Reorder = func(hook string, steps []string) error {
+			gotHook, gotSteps = hook, steps
+			return nil
+		}
+
+		reorderCmd.Run(reorderCmd, []string{"pre-commit", "lint", "test"})
+		assert.Equal(t, "pre-commit", gotHook)
+		assert.Equal(t, []string{"lint", "test"}, gotSteps)
+	})
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [hook] [git-args...]",
+	Short: "Run a hook",
+	Long: `Run the commands declared for a hook in the husky config.
+
+This command is called by the hooks installed in the git hooks directory,
+receiving the same arguments and input git passes to the hook.`,
+	Args:    cobra.MinimumNArgs(1),
+	Example: "husky run pre-commit",
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := lib.Run(lib.RunOptions{
+			Hook:  args[0],
+			Args:  args[1:],
+			Stdin: hookStdin(args[0], os.Stdin),
+			Quiet: quiet,
+		})
+		if err != nil {
+			tools.LogError("❌ Error running hook: %v\n", err)
+			os.Exit(1)
+		}
+
+		if result.Failed() {
+			tools.LogError("❌ Hook '%s' failed\n", result.Hook)
+			os.Exit(result.ExitCode())
+		}
+	},
+}
+
+// hookStdin returns the input of the hook, nil for the hooks git passes no input to:
+// an inherited stdin left open, e.g. by an editor, would block the hook otherwise
+func hookStdin(hook string, stdin *os.File) io.Reader {
+	info, ok := tools.LookupHook(hook)
+	if !ok || info.Stdin == "" || tools.IsTerminal(stdin) {
+		return nil
+	}
+	return stdin
+}
+
+func init() {
+	runCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	// everything after the hook name belongs to git
+	runCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCmd(t *testing.T) {
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "run [hook] [git-args...]", runCmd.Use)
+		assert.Equal(t, "Run a hook", runCmd.Short)
+		assert.Error(t, runCmd.Args(runCmd, []string{}))
+		assert.NoError(t, runCmd.Args(runCmd, []string{"commit-msg", ".git/COMMIT_EDITMSG"}))
+	})
+
+	t.Run("should pass flags after the hook to git args", func(t *testing.T) {
+		err := runCmd.Flags().Parse([]string{"-q", "pre-push", "origin", "--not-a-flag"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pre-push", "origin", "--not-a-flag"}, runCmd.Flags().Args())
+		quiet = false
+	})
+
+	t.Run("should only read stdin for the hooks git passes input to", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		assert.NoError(t, err)
+		defer r.Close()
+		defer w.Close()
+
+		assert.Nil(t, hookStdin("pre-commit", r))
+		assert.Nil(t, hookStdin("unknown-hook", r))
+		assert.Equal(t, r, hookStdin("pre-push", r))
+		assert.Equal(t, r, hookStdin("post-rewrite", r))
+	})
+
+	t.Run("should be registered in root command", func(t *testing.T) {
+		cmd, _, err := rootCmd.Find([]string{"run"})
+		assert.NoError(t, err)
+		assert.Equal(t, runCmd, cmd)
+	})
+}
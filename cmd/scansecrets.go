@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var secretsOptions lib.SecretsOptions
+
+var scanSecretsCmd = &cobra.Command{
+	Use:   "scan-secrets",
+	Short: "Scan the added lines for secrets",
+	Long: `Scan the lines added by the staged changes, or by the commits of a revision range,
+for credentials: AWS keys, private keys, JWTs, GitHub, Slack, Stripe and Google
+tokens, and high entropy values assigned to secret-like variables.
+
+Lines containing husky:allow-secret are skipped. The allowlist file, by default
+.husky/secrets-allowlist, holds one entry per line: path:<glob>, rule:<id>,
+fingerprint:<fingerprint>, or a regular expression matched against the secret.
+
+The scan runs offline. In the config, the same check is available as a step with
+builtin: secrets.`,
+	Args:    cobra.NoArgs,
+	Example: "husky scan-secrets\nhusky scan-secrets --range origin/main..HEAD --format sarif --output secrets.sarif",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lib.ScanSecrets(secretsOptions, cmd.OutOrStdout(), cmd.ErrOrStderr()); err != nil {
+			tools.LogError("❌ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanSecretsCmd)
+	scanSecretsCmd.Flags().StringVarP(&secretsOptions.Range, "range", "r", "", "Scan the commits of a revision range instead of the staged changes")
+	scanSecretsCmd.Flags().StringVarP(&secretsOptions.Format, "format", "f", lib.SecretsText, "Format of the report: text, json or sarif")
+	scanSecretsCmd.Flags().StringVarP(&secretsOptions.Output, "output", "o", "", "Write the report to a file")
+	scanSecretsCmd.Flags().StringVar(&secretsOptions.Allowlist, "allowlist", "", "Allowlist file (default .husky/secrets-allowlist)")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestScanSecretsCmd(t *testing.T) {
+	prevScan := lib.ScanSecrets
+	defer func() { lib.ScanSecrets = prevScan }()
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "scan-secrets", scanSecretsCmd.Use)
+		assert.Error(t, scanSecretsCmd.Args(scanSecretsCmd, []string{"file"}))
+		for _, flag := range []string{"range", "format", "output", "allowlist"} {
+			assert.NotNil(t, scanSecretsCmd.Flags().Lookup(flag))
+		}
+	})
+
+	t.Run("should pass the flags", func(t *testing.T) {
+		var got lib.SecretsOptions
+		lib.ScanSecrets = func(opts lib.SecretsOptions, stdout, stderr io.Writer) error {
+			got = opts
+			return nil
+		}
+
+		scanSecretsCmd.Flags().Set("range", "origin/main..HEAD")
+		scanSecretsCmd.Flags().Set("format", "sarif")
+		defer func() { secretsOptions = lib.SecretsOptions{Format: lib.SecretsText} }()
+
+		scanSecretsCmd.Run(scanSecretsCmd, []string{})
+		assert.Equal(t, lib.SecretsOptions{Range: "origin/main..HEAD", Format: "sarif"}, got)
+	})
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var source lib.TemplateSource
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage hook templates",
+	Long: `Templates are ready-made sets of hooks, applied with husky init --template
+or husky add --template: config templates declare steps in the config, script
+templates are written to .husky/scripts and run by a step of their hook.
+
+Besides the builtin templates, husky loads the ones of ~/.config/husky/templates,
+of the templates_dir of the config (.husky/templates) and of the git repositories
+pulled with husky templates pull, prefixed with the name of the repository.`,
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available templates",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lib.ListTemplates(cmd.OutOrStdout()); err != nil {
+			tools.LogError("❌ Error listing templates: %v\n", err)
+		}
+	},
+}
+
+var templatesShowCmd = &cobra.Command{
+	Use:     "show [template]",
+	Short:   "Show the content of a template",
+	Args:    cobra.ExactArgs(1),
+	Example: "husky templates show go",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lib.ShowTemplate(cmd.OutOrStdout(), args[0]); err != nil {
+			tools.LogError("❌ Error showing template: %v\n", err)
+		}
+	},
+}
+
+var templatesPullCmd = &cobra.Command{
+	Use:   "pull [url]",
+	Short: "Add the templates of a git repository",
+	Long: `Fetch the templates of a git repository, or of a local repository, pinned to
+a ref and cached in the user cache directory, and save the repository as a template
+source in the config so that everyone working on the project gets the same templates.`,
+	Args:    cobra.ExactArgs(1),
+	Example: "husky templates pull https://github.com/acme/hooks.git --ref v1.2.0 --name acme\nhusky add --template acme/service",
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source
+		src.URL = args[0]
+		if err := lib.PullTemplates(src); err != nil {
+			tools.LogError("❌ Error pulling templates: %v\n", err)
+		}
+	},
+}
+
+var templatesUpdateCmd = &cobra.Command{
+	Use:     "update [source...]",
+	Short:   "Fetch the template sources again",
+	Long:    `Fetch the template sources of the config again, or only the given ones, picking up the new commits of the refs they are pinned to.`,
+	Example: "husky templates update acme",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lib.UpdateTemplates(args); err != nil {
+			tools.LogError("❌ Error updating templates: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	templatesPullCmd.Flags().StringVar(&source.Ref, "ref", "", "Branch, tag or commit to pin (default HEAD)")
+	templatesPullCmd.Flags().StringVar(&source.Name, "name", "", "Prefix of the templates (default the repository name)")
+	templatesPullCmd.Flags().StringVar(&source.Path, "path", "", "Directory of the templates in the repository")
+	templatesCmd.AddCommand(templatesListCmd, templatesShowCmd, templatesPullCmd, templatesUpdateCmd)
+	rootCmd.AddCommand(templatesCmd)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/lib"
+)
+
+func TestTemplatesCmd(t *testing.T) {
+	prevList, prevShow, prevAddTemplate, prevInstall := lib.ListTemplates, lib.ShowTemplate, lib.AddTemplate, lib.Install
+	prevPull, prevUpdate := lib.PullTemplates, lib.UpdateTemplates
+	defer func() {
+		lib.ListTemplates, lib.ShowTemplate, lib.AddTemplate, lib.Install = prevList, prevShow, prevAddTemplate, prevInstall
+		lib.PullTemplates, lib.UpdateTemplates = prevPull, prevUpdate
+	}()
+
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "templates", templatesCmd.Use)
+		assert.Error(t, templatesShowCmd.Args(templatesShowCmd, []string{}))
+		assert.NotNil(t, initCmd.Flags().Lookup("template"))
+		assert.NotNil(t, addCmd.Flags().Lookup("template"))
+	})
+
+	t.Run("should list and show templates", func(t *testing.T) {
+		var shown string
+		lib.ListTemplates = func(w io.Writer) error {
+			_, err := io.WriteString(w, "go\n")
+			return err
+		}
+		lib.ShowTemplate = func(w io.Writer, name string) error {
+			shown = name
+			return nil
+		}
+
+		buf := new(bytes.Buffer)
+		templatesListCmd.SetOut(buf)
+		templatesListCmd.Run(templatesListCmd, nil)
+		assert.Equal(t, "go\n", buf.String())
+
+		templatesShowCmd.Run(templatesShowCmd, []string{"security"})
+		assert.Equal(t, "security", shown)
+	})
+
+	t.Run("should add a template with add --template", func(t *testing.T) {
+		var gotName, gotHook string
+		lib.AddTemplate = func(name, hook string, force bool) error {
+			gotName, gotHook = name, hook
+			return nil
+		}
+		lib.Install = func(opts lib.InstallOptions) error { return nil }
+
+		template = "examples/commit-msg"
+		defer func() { template = "" }()
+
+		assert.NoError(t, addCmd.Args(addCmd, []string{"commit-msg"}))
+		assert.Error(t, addCmd.Args(addCmd, []string{"commit-msg", "exit 0"}))
+		addCmd.Run(addCmd, []string{"commit-msg"})
+		assert.Equal(t, "examples/commit-msg", gotName)
+		assert.Equal(t, "commit-msg", gotHook)
+	})
+
+	t.Run("should pull and update template sources", func(t *testing.T) {
+		var pulled lib.TemplateSource
+		var updated []string
+		lib.PullTemplates = func(src lib.TemplateSource) error {
+			pulled = src
+			return nil
+		}
+		lib.UpdateTemplates = func(names []string) error {
+			updated = names
+			return nil
+		}
+
+		assert.NoError(t, templatesPullCmd.Flags().Parse([]string{"--ref", "v1", "--name", "acme"}))
+		templatesPullCmd.Run(templatesPullCmd, []string{"https://github.com/acme/hooks.git"})
+		assert.Equal(t, lib.TemplateSource{Name: "acme", URL: "https://github.com/acme/hooks.git", Ref: "v1"}, pulled)
+		source = lib.TemplateSource{}
+
+		templatesUpdateCmd.Run(templatesUpdateCmd, []string{"acme"})
+		assert.Equal(t, []string{"acme"}, updated)
+	})
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vkunssec/husky/internal/lib"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+var purge bool
+
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall husky",
+	Long: `Uninstall husky from the current directory.
+
+This command will:
+- Remove the hooks installed by husky
+- Restore the git hooks backed up at install time
+- Remove the .husky directory, with --purge`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := lib.UninstallOptions{
+			Purge: purge,
+			Quiet: quiet,
+		}
+
+		if err := lib.Uninstall(opts); err != nil {
+			tools.LogError("❌ Error uninstalling Husky: %v\n", err)
+			return
+		}
+
+		if !quiet {
+			tools.LogInfo("✅ Husky uninstalled successfully!")
+		}
+	},
+}
+
+func init() {
+	uninstallCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Silent mode")
+	uninstallCmd.Flags().BoolVar(&purge, "purge", false, "Remove the .husky directory")
+	rootCmd.AddCommand(uninstallCmd)
+}
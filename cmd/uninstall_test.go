@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUninstallCmd(t *testing.T) {
+	t.Run("should have correct command properties", func(t *testing.T) {
+		assert.Equal(t, "uninstall", uninstallCmd.Use)
+		assert.Equal(t, "Uninstall husky", uninstallCmd.Short)
+		assert.Contains(t, uninstallCmd.Long, "Restore the git hooks backed up at install time")
+	})
+
+	t.Run("should register flags", func(t *testing.T) {
+		for _, name := range []string{"quiet", "purge"} {
+			flag := uninstallCmd.Flags().Lookup(name)
+			assert.NotNil(t, flag)
+			assert.Equal(t, "false", flag.DefValue)
+		}
+	})
+
+	t.Run("should be registered in root command", func(t *testing.T) {
+		cmd, _, err := rootCmd.Find([]string{"uninstall"})
+		assert.NoError(t, err)
+		assert.Equal(t, uninstallCmd, cmd)
+	})
+}
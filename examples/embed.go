@@ -0,0 +1,9 @@
+// Package examples embeds the example hook scripts, offered as templates by husky
+package examples
+
+import "embed"
+
+// Hooks holds the example scripts, named after the hook they are written for
+//
+//go:embed commit-msg post-commit pre-commit
+var Hooks embed.FS
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -16,12 +17,40 @@ var (
 	Install = install
 )
 
+// AddOptions are the options for the add command
+type AddOptions struct {
+	Name   string // name of the step, added after the existing steps or replacing the one with the same name
+	Force  bool   // overwrite an existing hook or step without asking
+	Append bool   // add the command to the existing commands of the hook
+}
+
+// confirmOverwrite asks the user before replacing something, failing with the hint without a terminal
+func confirmOverwrite(what, hint string) error {
+	// prompting would block scripts and CI, where nobody can answer
+	if !tools.IsTerminal(os.Stdin) {
+		return fmt.Errorf("%s already exists, %s", strings.ToLower(what[:1])+what[1:], hint)
+	}
+
+	// ask if user wants to overwrite
+	fmt.Printf("%s already exists. Do you want to overwrite it? [y/N] ", what)
+	var response string
+	fmt.Scanln(&response)
+	if response != "y" && response != "Y" {
+		return fmt.Errorf("operation cancelled by user")
+	}
+	return nil
+}
+
 // add is the implementation of the Add function
-func add(hook string, cmd string) error {
+func add(hook string, cmd string, opts AddOptions) error {
 	if !tools.IsValidHook(hook) {
 		return errors.New("invalid hook")
 	}
 
+	if opts.Force && opts.Append {
+		return errors.New("--force and --append cannot be used together")
+	}
+
 	// check if .git exists
 	if !tools.GitExists() {
 		return errors.New("git not initialized")
@@ -46,43 +75,62 @@ func add(hook string, cmd string) error {
 		tools.LogInfo("created .husky/hooks")
 	}
 
-	// check if hook already exists
-	if _, err := os.Stat(path.Join(tools.GetHuskyHooksDir(true), hook)); err == nil {
-		// ask if user wants to overwrite
-		fmt.Printf("Hook '%s' already exists. Do you want to overwrite it? [y/N] ", hook)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			return fmt.Errorf("operation cancelled by user")
-		}
+	if strings.TrimSpace(cmd) == "" {
+		return errors.New("command cannot be empty")
 	}
 
-	// create hook
-	file, err := os.Create(path.Join(tools.GetHuskyHooksDir(true), hook))
+	config, err := LoadConfig()
 	if err != nil {
 		return err
 	}
 
-	defer file.Close()
-
-	if cmd == "" {
-		return errors.New("command cannot be empty")
+	// a hand-written script is replaced by the generated one, appending to it would lose it silently
+	hookPath := path.Join(tools.GetHuskyHooksDir(true), hook)
+	declared := config.Hooks[hook] != nil && len(config.Hooks[hook].Commands) > 0
+	if !declared && !opts.Force && (opts.Append || opts.Name != "") && isHandWrittenHook(hookPath) {
+		return fmt.Errorf("hook '%s' is a script not declared in the config and would be replaced, "+
+			"move its commands to the config or use --force to overwrite it", hook)
 	}
 
-	// Add shebang only if it doesn't exist
-	if !strings.HasPrefix(cmd, "#!/") {
-		cmd = "#!/bin/sh\n" + cmd
+	command := &HookCommand{Name: opts.Name, Run: cmd}
+	if opts.Name != "" {
+		if err := addStep(config.Hook(hook), command, opts.Force); err != nil {
+			return err
+		}
+	} else {
+		// check if hook already exists
+		_, statErr := os.Stat(hookPath)
+		exists := statErr == nil || declared
+		if exists && !opts.Force && !opts.Append {
+			hint := "use --force to overwrite it or --append to add the command to it"
+			if err := confirmOverwrite(fmt.Sprintf("Hook '%s'", hook), hint); err != nil {
+				return err
+			}
+		}
+
+		// declare the hook in the config file
+		if opts.Append {
+			config.Hook(hook).Commands = append(config.Hook(hook).Commands, command)
+		} else {
+			config.Hook(hook).Commands = []*HookCommand{command}
+		}
 	}
 
-	_, err = file.WriteString(cmd)
-	if err != nil {
-		return err
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	// Add execution permission to the file
-	if err := os.Chmod(path.Join(tools.GetHuskyHooksDir(true), hook), 0755); err != nil {
-		return fmt.Errorf("failed to set hook permissions: %w", err)
+	// create hook
+	content := renderHookScript(hook, config.Hooks[hook], config)
+	if err := createHook(tools.GetHuskyHooksDir(true), hook, content, config); err != nil {
+		return fmt.Errorf("failed to create hook: %w", err)
 	}
 
 	return nil
 }
+
+// isHandWrittenHook reports whether a hook script exists and was not generated by husky
+func isHandWrittenHook(hookPath string) bool {
+	content, err := os.ReadFile(hookPath)
+	return err == nil && !bytes.Contains(content, []byte(shimCommand))
+}
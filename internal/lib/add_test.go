@@ -3,6 +3,8 @@ package lib
 import (
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -45,7 +47,7 @@ func TestAdd(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := Add(tt.hook, tt.cmd)
+			err := Add(tt.hook, tt.cmd, AddOptions{Force: true})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Add() erro = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -56,7 +58,107 @@ func TestAdd(t *testing.T) {
 				if _, err := os.Stat(hookPath); os.IsNotExist(err) {
 					t.Errorf("Hook file was not created in %s", hookPath)
 				}
+
+				// Verify if the hook was declared in the config file
+				config, err := LoadConfig()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !config.Loaded() || len(config.Hooks[tt.hook].Commands) != 1 || config.Hooks[tt.hook].Commands[0].Run != tt.cmd {
+					t.Errorf("Hook was not declared in %s", config.Path())
+				}
 			}
 		})
 	}
 }
+
+func TestAddExistingHook(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky/hooks", 0755)
+
+	commands := func() []string {
+		config, err := LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs := []string{}
+		for _, command := range config.Hooks["pre-commit"].Commands {
+			runs = append(runs, command.Run)
+		}
+		return runs
+	}
+
+	if err := Add("pre-commit", "go vet ./...", AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// asking would block without a terminal
+	stdin := os.Stdin
+	r, w, _ := os.Pipe()
+	defer w.Close()
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	if err := Add("pre-commit", "go test ./...", AddOptions{}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Add() without a terminal error = %v, want a hint about --force", err)
+	}
+
+	if err := Add("pre-commit", "go test ./...", AddOptions{Append: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := commands(); !reflect.DeepEqual(got, []string{"go vet ./...", "go test ./..."}) {
+		t.Errorf("commands after --append = %v", got)
+	}
+
+	if err := Add("pre-commit", "make lint", AddOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := commands(); !reflect.DeepEqual(got, []string{"make lint"}) {
+		t.Errorf("commands after --force = %v", got)
+	}
+
+	if err := Add("pre-commit", "make lint", AddOptions{Force: true, Append: true}); err == nil {
+		t.Error("Add() with --force and --append should fail")
+	}
+}
+
+func TestAddHandWrittenHook(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky/hooks", 0755)
+	legacy := "#!/bin/sh\necho legacy-check\n"
+	os.WriteFile(".husky/hooks/pre-commit", []byte(legacy), 0755)
+
+	// appending would replace the script with the generated one
+	for _, opts := range []AddOptions{{Append: true}, {Name: "appended"}} {
+		err := Add("pre-commit", "echo appended", opts)
+		if err == nil || !strings.Contains(err.Error(), "--force") {
+			t.Errorf("Add(%+v) error = %v, want a hint about --force", opts, err)
+		}
+	}
+	content, _ := os.ReadFile(".husky/hooks/pre-commit")
+	if string(content) != legacy {
+		t.Errorf("script = %q, want it untouched", content)
+	}
+	if config, _ := LoadConfig(); config.Hooks["pre-commit"] != nil && len(config.Hooks["pre-commit"].Commands) > 0 {
+		t.Errorf("commands = %v, want none", config.Hooks["pre-commit"].Commands)
+	}
+
+	if err := Add("pre-commit", "echo appended", AddOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ = os.ReadFile(".husky/hooks/pre-commit")
+	if !strings.Contains(string(content), shimCommand) {
+		t.Errorf("script = %q, want the generated hook", content)
+	}
+}
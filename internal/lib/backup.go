@@ -0,0 +1,168 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// shimCommand identifies the hooks generated by husky
+const shimCommand = "exec husky run"
+
+// backupTimeFormat is the format of the backup directory names, which sorts chronologically
+const backupTimeFormat = "20060102-150405"
+
+// getBackupDir returns the directory holding the backups of the git hooks
+func getBackupDir() string {
+	return filepath.Join(tools.GetHuskyDir(true), "_backup")
+}
+
+// isManagedHook reports whether the hook installed in the git hooks directory belongs to husky
+func isManagedHook(gitHook, huskyHook string) bool {
+	if link, err := os.Readlink(gitHook); err == nil {
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(gitHook), link)
+		}
+		if samePath(link, huskyHook) {
+			return true
+		}
+	}
+
+	gitInfo, err := os.Stat(gitHook)
+	if err != nil {
+		return false
+	}
+	if huskyInfo, err := os.Stat(huskyHook); err == nil && os.SameFile(gitInfo, huskyInfo) {
+		return true
+	}
+
+	content, err := os.ReadFile(gitHook)
+	if err != nil {
+		return false
+	}
+	if bytes.Contains(content, []byte(shimCommand)) || bytes.Contains(content, []byte(chainMarker)) {
+		return true
+	}
+	huskyContent, err := os.ReadFile(huskyHook)
+	return err == nil && bytes.Equal(content, huskyContent)
+}
+
+// foreignHooks returns the files of the git hooks directory not managed by husky
+func foreignHooks(gitHooksDir, huskyHooksDir string) ([]string, error) {
+	entries, err := os.ReadDir(gitHooksDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), chainedSuffix) {
+			continue
+		}
+		if isManagedHook(filepath.Join(gitHooksDir, entry.Name()), filepath.Join(huskyHooksDir, entry.Name())) {
+			continue
+		}
+		hooks = append(hooks, entry.Name())
+	}
+	return hooks, nil
+}
+
+// backupHooks copies the given hooks of the git hooks directory to a new
+// timestamped directory in .husky/_backup and returns its path
+func backupHooks(gitHooksDir string, hooks []string) (string, error) {
+	if len(hooks) == 0 {
+		return "", nil
+	}
+
+	root := getBackupDir()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+
+	// backups are local to the clone and must not be committed
+	gitignore := filepath.Join(root, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		if err := os.WriteFile(gitignore, []byte("*\n"), 0644); err != nil {
+			return "", err
+		}
+	}
+
+	name := time.Now().UTC().Format(backupTimeFormat)
+	dir := filepath.Join(root, name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = filepath.Join(root, fmt.Sprintf("%s-%d", name, i))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	for _, hook := range hooks {
+		if err := copyFile(filepath.Join(gitHooksDir, hook), filepath.Join(dir, hook)); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", hook, err)
+		}
+	}
+
+	return dir, nil
+}
+
+// listBackups returns the backup directories from the oldest to the newest
+func listBackups() ([]string, error) {
+	entries, err := os.ReadDir(getBackupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			backups = append(backups, filepath.Join(getBackupDir(), entry.Name()))
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// copyFile copies a file keeping its permissions
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, info.Mode().Perm())
+}
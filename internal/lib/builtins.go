@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// builtinContext is what a builtin receives from the runner
+type builtinContext struct {
+	hook    string
+	input   *HookInput
+	config  *HuskyConfig      // config of the package the step is declared in
+	dir     string            // directory the step runs in, empty for the root of the repository
+	files   []string          // files matched by the filters of the step, relative to the root of the repository
+	removed []string          // files deleted or renamed away by the changes, for the builtins with removed set
+	options map[string]string // options of the step, declared with with:
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// builtinFunc is a check implemented by husky, run in-process instead of a shell command.
+// It reports the details of a failure on stderr and returns a short error
+type builtinFunc func(ctx *builtinContext) error
+
+// builtin is a check steps can declare with builtin: <name>
+type builtin struct {
+	run     builtinFunc
+	files   []string // globs of the files the builtin works on, it is skipped when none of them changed
+	options []string // options accepted in with:
+	removed bool     // the builtin also needs the matching files deleted or renamed away by the changes
+}
+
+// builtins are the builtins by name
+var builtins = map[string]builtin{
+	"commit-msg":         {run: builtinCommitMsg},
+	"prepare-commit-msg": {run: builtinPrepareCommitMsg},
+	"go:fmt":             {run: builtinGoFmt, files: []string{"*.go"}, options: []string{"fix"}},
+	"go:vet":             {run: builtinGoVet, files: goFiles, options: []string{"args"}},
+	"go:test":            {run: builtinGoTest, files: goFiles, options: []string{"args", "affected"}, removed: true},
+	"go:build":           {run: builtinGoBuild, files: goFiles, options: []string{"args", "affected"}, removed: true},
+	"go:mod-tidy-check":  {run: builtinGoModTidyCheck, files: goFiles},
+	"secrets":            {run: builtinSecrets, options: []string{"format", "output", "allowlist"}},
+}
+
+// lookupBuiltin returns a builtin by name
+func lookupBuiltin(name string) (builtin, bool) {
+	b, ok := builtins[name]
+	return b, ok
+}
+
+// builtinNames returns the names of the builtins, sorted
+func builtinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateBuiltin checks that the builtin exists and accepts the options of the step
+func validateBuiltin(name string, options map[string]string) error {
+	b, ok := lookupBuiltin(name)
+	if !ok {
+		return fmt.Errorf("unknown builtin '%s', use one of: %s", name, strings.Join(builtinNames(), ", "))
+	}
+	for option := range options {
+		if !contains(b.options, option) {
+			if len(b.options) == 0 {
+				return fmt.Errorf("builtin '%s' has no options", name)
+			}
+			return fmt.Errorf("unknown option '%s' of builtin '%s', use one of: %s", option, name, strings.Join(b.options, ", "))
+		}
+	}
+	return nil
+}
+
+// runBuiltin runs the builtin of a task
+func runBuiltin(t *task, input *HookInput, stdout, stderr io.Writer) error {
+	b, _ := lookupBuiltin(t.command.Builtin)
+	return b.run(&builtinContext{
+		hook:    input.Hook,
+		input:   input,
+		config:  t.config,
+		dir:     t.dir,
+		files:   t.files,
+		removed: t.removed,
+		options: t.command.With,
+		stdout:  stdout,
+		stderr:  stderr,
+	})
+}
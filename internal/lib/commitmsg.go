@@ -0,0 +1,427 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// Formats of the commit message header
+const (
+	FormatConventional = "conventional" // <type>[(scope)][!]: <subject>, the default
+	FormatFree         = "free"         // any header, only the other rules apply
+)
+
+// CommitMsgFormats are the supported commit message formats
+var CommitMsgFormats = []string{FormatConventional, FormatFree}
+
+// defaultCommitTypes are the types of the Conventional Commits specification and of the Angular convention
+var defaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// defaultCommitIgnore matches the headers git writes for merges and reverts, and the autosquash ones
+var defaultCommitIgnore = []string{`^Merge `, `^Revert "`, `^(fixup|squash|amend)! `}
+
+const (
+	defaultHeaderMaxLength   = 72
+	defaultBodyMaxLineLength = 100
+)
+
+// scissorsLine is the line below which git drops the message, e.g. the diff of commit --verbose
+const scissorsLine = " ------------------------ >8 ------------------------"
+
+// exported functions
+var (
+	CheckCommitMsg = checkCommitMsg
+)
+
+// CommitMsgConfig are the rules commit messages are checked against
+type CommitMsgConfig struct {
+	Format            string   `yaml:"format,omitempty" toml:"format,omitempty" json:"format,omitempty"`
+	Types             []string `yaml:"types,omitempty" toml:"types,omitempty" json:"types,omitempty"`    // allowed types, the Conventional Commits ones by default
+	Scopes            []string `yaml:"scopes,omitempty" toml:"scopes,omitempty" json:"scopes,omitempty"` // allowed scopes, any by default
+	ScopeRequired     bool     `yaml:"scope_required,omitempty" toml:"scope_required,omitempty" json:"scope_required,omitempty"`
+	HeaderMaxLength   int      `yaml:"header_max_length,omitempty" toml:"header_max_length,omitempty" json:"header_max_length,omitempty"`          // 72 by default, -1 disables the rule
+	BodyMaxLineLength int      `yaml:"body_max_line_length,omitempty" toml:"body_max_line_length,omitempty" json:"body_max_line_length,omitempty"` // 100 by default, -1 disables the rule
+	Trailers          []string `yaml:"trailers,omitempty" toml:"trailers,omitempty" json:"trailers,omitempty"`                                     // trailers every message needs, e.g. Signed-off-by
+	Ticket            string   `yaml:"ticket,omitempty" toml:"ticket,omitempty" json:"ticket,omitempty"`                                           // regular expression of the ticket ID the message must mention
+	Ignore            []string `yaml:"ignore,omitempty" toml:"ignore,omitempty" json:"ignore,omitempty"`                                           // regular expressions of the headers not checked
+}
+
+// Validate checks the format, the lengths and the regular expressions of the rules
+func (c *CommitMsgConfig) Validate() error {
+	if c.Format != "" && c.Format != FormatConventional && c.Format != FormatFree {
+		return fmt.Errorf("invalid format '%s', use one of: %s", c.Format, strings.Join(CommitMsgFormats, ", "))
+	}
+	if c.HeaderMaxLength < -1 || c.BodyMaxLineLength < -1 {
+		return errors.New("lengths must be positive, or -1 to disable the rule")
+	}
+	if c.Ticket != "" {
+		if _, err := regexp.Compile(c.Ticket); err != nil {
+			return fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+	}
+	for _, pattern := range c.Ignore {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid ignore pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the rules with the defaults of the unset ones
+func (c *CommitMsgConfig) withDefaults() CommitMsgConfig {
+	rules := CommitMsgConfig{}
+	if c != nil {
+		rules = *c
+	}
+	if rules.Format == "" {
+		rules.Format = FormatConventional
+	}
+	if len(rules.Types) == 0 {
+		rules.Types = defaultCommitTypes
+	}
+	if rules.HeaderMaxLength == 0 {
+		rules.HeaderMaxLength = defaultHeaderMaxLength
+	}
+	if rules.BodyMaxLineLength == 0 {
+		rules.BodyMaxLineLength = defaultBodyMaxLineLength
+	}
+	if len(rules.Ignore) == 0 {
+		rules.Ignore = defaultCommitIgnore
+	}
+	return rules
+}
+
+// commitLine is a line of the message with its number in the file
+type commitLine struct {
+	number int
+	text   string
+}
+
+// commitTrailer is a footer of the message, e.g. Signed-off-by: Jane <jane@example.com>
+type commitTrailer struct {
+	key   string
+	value string
+	line  commitLine
+}
+
+// commitMessage is a commit message split in header, body and trailers, with the
+// Conventional Commits parts of the header
+type commitMessage struct {
+	lines    []commitLine // the lines git keeps: no comments, nothing below the scissors
+	header   commitLine
+	body     []commitLine
+	trailers []commitTrailer
+
+	kind      string
+	scope     string
+	breaking  bool
+	subject   string
+	scopeCol  int
+	headerErr *commitMsgProblem // why the header does not follow Conventional Commits
+}
+
+// commitMsgProblem is a rule the message breaks, located in the message file
+type commitMsgProblem struct {
+	line    commitLine // zero when the problem concerns the whole message
+	column  int        // 1-based, 0 for the whole line
+	message string
+}
+
+var trailerPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE)(: | #)(.*)$`)
+
+// parseCommitMessage parses a commit message the way git cleans it up: comment
+// lines and everything below the scissors line are dropped
+func parseCommitMessage(content, commentChar string) *commitMessage {
+	msg := &commitMessage{}
+
+	for i, text := range strings.Split(content, "\n") {
+		text = strings.TrimSuffix(text, "\r")
+		if text == commentChar+scissorsLine {
+			break
+		}
+		if strings.HasPrefix(text, commentChar) {
+			continue
+		}
+		msg.lines = append(msg.lines, commitLine{i + 1, text})
+	}
+
+	// git strips the leading and trailing blank lines
+	for len(msg.lines) > 0 && strings.TrimSpace(msg.lines[0].text) == "" {
+		msg.lines = msg.lines[1:]
+	}
+	for len(msg.lines) > 0 && strings.TrimSpace(msg.lines[len(msg.lines)-1].text) == "" {
+		msg.lines = msg.lines[:len(msg.lines)-1]
+	}
+	if len(msg.lines) == 0 {
+		return msg
+	}
+
+	msg.header = msg.lines[0]
+	msg.body = msg.lines[1:]
+
+	// the trailers are the last paragraph when all of its lines are trailers or their continuation
+	start := len(msg.body)
+	for start > 0 && strings.TrimSpace(msg.body[start-1].text) != "" {
+		start--
+	}
+	trailers := []commitTrailer{}
+	for _, line := range msg.body[start:] {
+		if m := trailerPattern.FindStringSubmatch(line.text); m != nil {
+			trailers = append(trailers, commitTrailer{key: m[1], value: m[3], line: line})
+		} else if len(trailers) > 0 && (line.text[0] == ' ' || line.text[0] == '\t') {
+			trailers[len(trailers)-1].value += "\n" + strings.TrimSpace(line.text)
+		} else {
+			trailers = nil
+			break
+		}
+	}
+	if len(trailers) > 0 && start > 0 {
+		msg.trailers = trailers
+		msg.body = msg.body[:start]
+	}
+	for len(msg.body) > 0 && strings.TrimSpace(msg.body[len(msg.body)-1].text) == "" {
+		msg.body = msg.body[:len(msg.body)-1]
+	}
+
+	msg.parseHeader()
+	for _, trailer := range msg.trailers {
+		if trailer.key == "BREAKING CHANGE" || trailer.key == "BREAKING-CHANGE" {
+			msg.breaking = true
+		}
+	}
+	return msg
+}
+
+// parseHeader parses <type>[(scope)][!]: <subject>, recording where it stops matching
+func (m *commitMessage) parseHeader() {
+	h := m.header.text
+	fail := func(i int, format string, args ...any) {
+		m.headerErr = &commitMsgProblem{line: m.header, column: column(h, i), message: fmt.Sprintf(format, args...)}
+	}
+
+	i := 0
+	for i < len(h) && (h[i] >= 'a' && h[i] <= 'z' || h[i] >= 'A' && h[i] <= 'Z') {
+		i++
+	}
+	if i == 0 {
+		fail(0, "the header must start with a type, e.g. 'feat: add login'")
+		return
+	}
+	m.kind = h[:i]
+
+	if i < len(h) && h[i] == '(' {
+		end := strings.IndexByte(h[i:], ')')
+		if end < 0 {
+			fail(i, "the scope is not closed")
+			return
+		}
+		m.scope, m.scopeCol = h[i+1:i+end], column(h, i+1)
+		if strings.TrimSpace(m.scope) == "" {
+			fail(i, "the scope is empty")
+			return
+		}
+		i += end + 1
+	}
+
+	if i < len(h) && h[i] == '!' {
+		m.breaking = true
+		i++
+	}
+
+	if i >= len(h) || h[i] != ':' {
+		fail(i, "expected ':' after the type")
+		return
+	}
+	i++
+	if i >= len(h) || h[i] != ' ' {
+		fail(i, "expected a space after ':'")
+		return
+	}
+	i++
+	if i < len(h) && (h[i] == ' ' || h[i] == '\t') {
+		fail(i, "expected a single space after ':'")
+		return
+	}
+	if strings.TrimSpace(h[i:]) == "" {
+		fail(i, "the subject is empty")
+		return
+	}
+	m.subject = h[i:]
+}
+
+// column returns the 1-based column of a byte offset, counting characters
+func column(s string, offset int) int {
+	return utf8.RuneCountInString(s[:offset]) + 1
+}
+
+// check returns the rules the message breaks
+func (m *commitMessage) check(rules CommitMsgConfig) []commitMsgProblem {
+	if len(m.lines) == 0 {
+		return []commitMsgProblem{{message: "the commit message is empty"}}
+	}
+	for _, pattern := range rules.Ignore {
+		if regexp.MustCompile(pattern).MatchString(m.header.text) {
+			return nil
+		}
+	}
+
+	problems := []commitMsgProblem{}
+	add := func(line commitLine, column int, format string, args ...any) {
+		problems = append(problems, commitMsgProblem{line, column, fmt.Sprintf(format, args...)})
+	}
+
+	if length := utf8.RuneCountInString(m.header.text); rules.HeaderMaxLength > 0 && length > rules.HeaderMaxLength {
+		add(m.header, rules.HeaderMaxLength+1, "the header is %d characters long, the limit is %d", length, rules.HeaderMaxLength)
+	}
+
+	if rules.Format == FormatConventional {
+		switch {
+		case m.headerErr != nil:
+			problems = append(problems, *m.headerErr)
+		case !contains(rules.Types, m.kind):
+			add(m.header, 1, "type '%s' is not allowed, use one of: %s", m.kind, strings.Join(rules.Types, ", "))
+		}
+		if m.headerErr == nil && m.scope == "" && rules.ScopeRequired {
+			add(m.header, column(m.header.text, len(m.kind)), "a scope is required, e.g. '%s(api): ...'", m.kind)
+		}
+		if m.scope != "" && len(rules.Scopes) > 0 {
+			offset := 0
+			for _, scope := range strings.Split(m.scope, ",") {
+				if name := strings.TrimSpace(scope); !contains(rules.Scopes, name) {
+					add(m.header, m.scopeCol+offset+strings.Index(scope, name), "scope '%s' is not allowed, use one of: %s", name, strings.Join(rules.Scopes, ", "))
+				}
+				offset += utf8.RuneCountInString(scope) + 1
+			}
+		}
+	}
+
+	if len(m.lines) > 1 && strings.TrimSpace(m.lines[1].text) != "" {
+		add(m.lines[1], 0, "the header must be followed by a blank line")
+	}
+
+	if rules.BodyMaxLineLength > 0 {
+		for _, line := range m.lines[1:] {
+			// a line without spaces, e.g. a URL, cannot be wrapped
+			length := utf8.RuneCountInString(line.text)
+			if length > rules.BodyMaxLineLength && strings.ContainsAny(strings.TrimSpace(line.text), " \t") {
+				add(line, rules.BodyMaxLineLength+1, "the line is %d characters long, the limit is %d", length, rules.BodyMaxLineLength)
+			}
+		}
+	}
+
+	for _, key := range rules.Trailers {
+		found := false
+		for _, trailer := range m.trailers {
+			found = found || strings.EqualFold(trailer.key, key)
+		}
+		if !found {
+			add(commitLine{}, 0, "the trailer '%s' is missing, e.g. '%s: ...' in the last paragraph", key, key)
+		}
+	}
+
+	if rules.Ticket != "" {
+		text := []string{}
+		for _, line := range m.lines {
+			text = append(text, line.text)
+		}
+		if !regexp.MustCompile(rules.Ticket).MatchString(strings.Join(text, "\n")) {
+			add(commitLine{}, 0, "no ticket ID matching '%s' is mentioned", rules.Ticket)
+		}
+	}
+
+	return problems
+}
+
+// contains reports whether the list contains the value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatCommitMsgProblems formats the problems like compiler errors, pointing at the column
+func formatCommitMsgProblems(file string, problems []commitMsgProblem) string {
+	var sb strings.Builder
+	for _, p := range problems {
+		switch {
+		case p.line.number == 0:
+			sb.WriteString(fmt.Sprintf("%s: %s\n", file, p.message))
+			continue
+		case p.column == 0:
+			sb.WriteString(fmt.Sprintf("%s:%d: %s\n", file, p.line.number, p.message))
+		default:
+			sb.WriteString(fmt.Sprintf("%s:%d:%d: %s\n", file, p.line.number, p.column, p.message))
+		}
+		sb.WriteString("    " + p.line.text + "\n")
+		if p.column > 0 {
+			// keep the tabs of the line so the caret lines up
+			pad := []rune{}
+			for i, r := range []rune(p.line.text + strings.Repeat(" ", p.column)) {
+				if i >= p.column-1 {
+					break
+				}
+				if r != '\t' {
+					r = ' '
+				}
+				pad = append(pad, r)
+			}
+			sb.WriteString("    " + string(pad) + "^\n")
+		}
+	}
+	return sb.String()
+}
+
+// commentChar returns the character starting the comment lines of commit messages
+func commentChar() string {
+	char := tools.GetGitConfig("core.commentChar")
+	if char == "" || char == "auto" {
+		return "#"
+	}
+	return char
+}
+
+// checkCommitMsgFile checks a commit message file, writing the problems to w
+func checkCommitMsgFile(file string, rules *CommitMsgConfig, w io.Writer) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	problems := parseCommitMessage(string(content), commentChar()).check(rules.withDefaults())
+	if len(problems) == 0 {
+		return nil
+	}
+
+	fmt.Fprint(w, formatCommitMsgProblems(file, problems))
+	if len(problems) == 1 {
+		return errors.New("the commit message breaks 1 rule")
+	}
+	return fmt.Errorf("the commit message breaks %d rules", len(problems))
+}
+
+// checkCommitMsg checks a commit message file against the rules of the config
+func checkCommitMsg(file string, w io.Writer) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	return checkCommitMsgFile(file, config.CommitMsg, w)
+}
+
+// builtinCommitMsg checks the message of commit-msg against the rules of the config
+func builtinCommitMsg(ctx *builtinContext) error {
+	if ctx.input.CommitMsgFile == "" {
+		return fmt.Errorf("no commit message file, the builtin runs in commit-msg, not in %s", ctx.hook)
+	}
+	return checkCommitMsgFile(ctx.input.CommitMsgFile, ctx.config.CommitMsg, ctx.stderr)
+}
@@ -0,0 +1,155 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	content := `feat(api,auth)!: add token refresh
+
+Tokens are refreshed before they expire.
+# Please enter the commit message for your changes.
+
+Refs: PROJ-42
+BREAKING CHANGE: the /token endpoint
+  returns a JSON object
+Signed-off-by: Jane <jane@example.com>
+# ------------------------ >8 ------------------------
+diff --git a/main.go b/main.go
+`
+	msg := parseCommitMessage(content, "#")
+
+	assert.Nil(t, msg.headerErr)
+	assert.Equal(t, "feat", msg.kind)
+	assert.Equal(t, "api,auth", msg.scope)
+	assert.Equal(t, "add token refresh", msg.subject)
+	assert.True(t, msg.breaking)
+	assert.Len(t, msg.body, 2)
+	if assert.Len(t, msg.trailers, 3) {
+		assert.Equal(t, "Refs", msg.trailers[0].key)
+		assert.Equal(t, "the /token endpoint\nreturns a JSON object", msg.trailers[1].value)
+		assert.Equal(t, 9, msg.trailers[2].line.number)
+	}
+
+	// a last paragraph that is not made of trailers belongs to the body
+	msg = parseCommitMessage("fix: typo\n\nSee the docs: they explain it\nfor real\n", "#")
+	assert.Empty(t, msg.trailers)
+	assert.Len(t, msg.body, 3)
+
+	// the header alone is not a trailer
+	msg = parseCommitMessage("Refs: PROJ-1\n", ";")
+	assert.Empty(t, msg.trailers)
+}
+
+func TestCheckCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   CommitMsgConfig
+		content string
+		want    []string // "line:column: message" of each problem
+	}{
+		{"Valid message", CommitMsgConfig{}, "feat(api): add login\n\nBody.\n", nil},
+		{"Breaking change", CommitMsgConfig{}, "fix!: drop the v1 API\n", nil},
+		{"Merge commits are ignored", CommitMsgConfig{}, "Merge branch 'main' into feature\n", nil},
+		{"Fixups are ignored", CommitMsgConfig{}, "fixup! feat: add login\n", nil},
+		{"Empty message", CommitMsgConfig{}, "# only comments\n\n", []string{"0:0: the commit message is empty"}},
+		{"Missing type", CommitMsgConfig{}, "add login\n", []string{"1:4: expected ':' after the type"}},
+		{"Missing space", CommitMsgConfig{}, "feat:add login\n", []string{"1:6: expected a space after ':'"}},
+		{"Unclosed scope", CommitMsgConfig{}, "feat(api: add login\n", []string{"1:5: the scope is not closed"}},
+		{"Empty subject", CommitMsgConfig{}, "feat: \n", []string{"1:7: the subject is empty"}},
+		{"No type", CommitMsgConfig{}, "(api): add login\n", []string{"1:1: the header must start with a type, e.g. 'feat: add login'"}},
+		{"Unknown type", CommitMsgConfig{}, "feet: add login\n", []string{"1:1: type 'feet' is not allowed, use one of: " + strings.Join(defaultCommitTypes, ", ")}},
+		{"Custom types", CommitMsgConfig{Types: []string{"feature"}}, "feature: add login\n", nil},
+		{"Scope required", CommitMsgConfig{ScopeRequired: true}, "feat: add login\n", []string{"1:5: a scope is required, e.g. 'feat(api): ...'"}},
+		{"Scope not allowed", CommitMsgConfig{Scopes: []string{"api", "ui"}}, "feat(api, db): add login\n", []string{"1:11: scope 'db' is not allowed, use one of: api, ui"}},
+		{"Long header", CommitMsgConfig{HeaderMaxLength: 20}, "feat: add the login page\n", []string{"1:21: the header is 24 characters long, the limit is 20"}},
+		{"Header length disabled", CommitMsgConfig{HeaderMaxLength: -1}, "feat: " + strings.Repeat("x", 100) + "\n", nil},
+		{"Missing blank line", CommitMsgConfig{}, "feat: add login\nBody.\n", []string{"2:0: the header must be followed by a blank line"}},
+		{"Long body line", CommitMsgConfig{BodyMaxLineLength: 10}, "feat: add login\n\nthe body is long\nhttps://example.com/long/url\n", []string{"3:11: the line is 16 characters long, the limit is 10"}},
+		{"Free format", CommitMsgConfig{Format: FormatFree}, "Add login\n", nil},
+		{"Missing trailer", CommitMsgConfig{Trailers: []string{"Signed-off-by"}}, "feat: add login\n\nsigned-off-by: Jane\n", nil},
+		{"Trailer in the body", CommitMsgConfig{Trailers: []string{"Signed-off-by"}}, "feat: add login\n", []string{"0:0: the trailer 'Signed-off-by' is missing, e.g. 'Signed-off-by: ...' in the last paragraph"}},
+		{"Ticket mentioned", CommitMsgConfig{Ticket: `[A-Z]+-[0-9]+`}, "feat: add login\n\nRefs: PROJ-42\n", nil},
+		{"Ticket missing", CommitMsgConfig{Ticket: `[A-Z]+-[0-9]+`}, "feat: add login\n", []string{"0:0: no ticket ID matching '[A-Z]+-[0-9]+' is mentioned"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := parseCommitMessage(tt.content, "#").check(tt.rules.withDefaults())
+			got := []string{}
+			for _, p := range problems {
+				got = append(got, fmt.Sprintf("%d:%d: %s", p.line.number, p.column, p.message))
+			}
+			want := tt.want
+			if want == nil {
+				want = []string{}
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestFormatCommitMsgProblems(t *testing.T) {
+	msg := parseCommitMessage("feat(web): add login\n", "#")
+	out := formatCommitMsgProblems(".git/COMMIT_EDITMSG", msg.check((&CommitMsgConfig{Scopes: []string{"api"}}).withDefaults()))
+	assert.Equal(t, ".git/COMMIT_EDITMSG:1:6: scope 'web' is not allowed, use one of: api\n    feat(web): add login\n         ^\n", out)
+}
+
+func TestCommitMsgConfigValidate(t *testing.T) {
+	assert.NoError(t, (&CommitMsgConfig{Format: FormatFree, Ticket: `#\d+`}).Validate())
+	assert.Error(t, (&CommitMsgConfig{Format: "angular"}).Validate())
+	assert.Error(t, (&CommitMsgConfig{Ticket: `[`}).Validate())
+	assert.Error(t, (&CommitMsgConfig{Ignore: []string{`(`}}).Validate())
+	assert.Error(t, (&CommitMsgConfig{HeaderMaxLength: -2}).Validate())
+
+	hook := &HookConfig{Commands: []*HookCommand{{Builtin: "commit-msg"}}}
+	assert.NoError(t, hook.Validate())
+	hook.Commands[0].Run = "exit 0"
+	assert.ErrorContains(t, hook.Validate(), "both run and builtin")
+	hook.Commands[0] = &HookCommand{Builtin: "unknown"}
+	assert.ErrorContains(t, hook.Validate(), "unknown builtin")
+}
+
+func TestBuiltinCommitMsg(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky", 0755)
+	config := `commit_msg:
+  scopes: [api]
+hooks:
+  commit-msg:
+    commands:
+      - builtin: commit-msg
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+	os.WriteFile(".git/COMMIT_EDITMSG", []byte("feat(web): add login\n"), 0644)
+
+	stderr := new(bytes.Buffer)
+	result, err := Run(RunOptions{Hook: "commit-msg", Args: []string{".git/COMMIT_EDITMSG"}, Stdout: new(bytes.Buffer), Stderr: stderr, Quiet: true})
+	assert.NoError(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, 1, result.ExitCode())
+	assert.Equal(t, "commit-msg", result.Commands[0].Name)
+	assert.Contains(t, stderr.String(), "[commit-msg] .git/COMMIT_EDITMSG:1:6: scope 'web' is not allowed")
+	assert.Contains(t, stderr.String(), "[commit-msg] the commit message breaks 1 rule")
+
+	os.WriteFile(".git/COMMIT_EDITMSG", []byte("feat(api): add login\n"), 0644)
+	result, err = Run(RunOptions{Hook: "commit-msg", Args: []string{".git/COMMIT_EDITMSG"}, Stdout: new(bytes.Buffer), Stderr: new(bytes.Buffer), Quiet: true})
+	assert.NoError(t, err)
+	assert.False(t, result.Failed())
+
+	out := new(bytes.Buffer)
+	assert.Error(t, CheckCommitMsg(".git/missing", out))
+	os.WriteFile("msg", []byte("docs: fix typo\n"), 0644)
+	assert.NoError(t, CheckCommitMsg("msg", out))
+}
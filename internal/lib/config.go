@@ -1,66 +1,383 @@
 package lib
 
-import "os"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/vkunssec/husky/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// HookTemplate is a named set of hooks: a config fragment declaring hooks, or a script run by Hook
 type HookTemplate struct {
-	Name     string
-	Content  string
-	Validate func(string) error
+	Name        string
+	Description string
+	Hook        string // hook the script is written for, empty for config templates
+	Source      string // where the template comes from: builtin, a directory or a git source
+	Content     string
+	Validate    func(string) error // checks the content before the template is written
 }
 
-const defaultPreCommitTemplate = `#!/bin/sh
-# Husky pre-commit hook
-set -e
+// DefaultHooks are the hooks init declares in a new config
+var DefaultHooks = []string{"pre-commit", "pre-push", "post-commit"}
+
+// ConfigFileNames are the config file names husky looks for inside .husky, in order of precedence
+var ConfigFileNames = []string{"husky.yaml", "husky.yml", "husky.toml", "husky.json"}
 
-# Validate that we're in a Git repository
-if [ ! -d .git ]; then
-    echo "Error: not a git repository"
-    exit 1
-fi
+// ErrConfigNotFound is returned when no config file exists in .husky
+var ErrConfigNotFound = errors.New("husky config file not found")
 
-# Add your pre-commit commands here
-`
+// FileMode is an os.FileMode serialized as an octal string (e.g. "0755")
+type FileMode os.FileMode
 
-const defaultPrePushTemplate = `#!/bin/sh
-# Husky pre-push hook
-set -e
+// MarshalText implements encoding.TextMarshaler
+func (m FileMode) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%04o", uint32(m))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *FileMode) UnmarshalText(text []byte) error {
+	v, err := strconv.ParseUint(strings.TrimPrefix(string(text), "0o"), 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid permissions %q: %w", text, err)
+	}
+	*m = FileMode(v)
+	return nil
+}
 
-# Add your pre-push commands here
-`
+// HookCommand is a single command executed by a hook
+type HookCommand struct {
+	Name       string            `yaml:"name,omitempty" toml:"name,omitempty" json:"name,omitempty"`
+	Run        string            `yaml:"run,omitempty" toml:"run,omitempty" json:"run,omitempty"`
+	Builtin    string            `yaml:"builtin,omitempty" toml:"builtin,omitempty" json:"builtin,omitempty"` // check implemented by husky, instead of run
+	With       map[string]string `yaml:"with,omitempty" toml:"with,omitempty" json:"with,omitempty"`          // options of the builtin
+	Needs      []string          `yaml:"needs,omitempty" toml:"needs,omitempty" json:"needs,omitempty"`
+	Glob       []string          `yaml:"glob,omitempty" toml:"glob,omitempty" json:"glob,omitempty"`
+	Exclude    []string          `yaml:"exclude,omitempty" toml:"exclude,omitempty" json:"exclude,omitempty"`
+	StageFixed bool              `yaml:"stage_fixed,omitempty" toml:"stage_fixed,omitempty" json:"stage_fixed,omitempty"`
+	SkipCI     bool              `yaml:"skip_ci,omitempty" toml:"skip_ci,omitempty" json:"skip_ci,omitempty"`
+}
 
-const defaultPostCommitTemplate = `#!/bin/sh
-# Husky post-commit hook
-set -e
+// TemplateSource is a git repository publishing templates, pinned to a ref
+type TemplateSource struct {
+	Name string `yaml:"name,omitempty" toml:"name,omitempty" json:"name,omitempty"` // prefix of its templates, defaults to the repository name
+	URL  string `yaml:"url" toml:"url" json:"url"`
+	Ref  string `yaml:"ref,omitempty" toml:"ref,omitempty" json:"ref,omitempty"`    // branch, tag or commit, defaults to HEAD
+	Path string `yaml:"path,omitempty" toml:"path,omitempty" json:"path,omitempty"` // directory of the templates in the repository
+}
 
-# Add your post-commit commands here
-`
+// HookConfig is the declaration of a hook in the config file
+type HookConfig struct {
+	Disabled bool           `yaml:"disabled,omitempty" toml:"disabled,omitempty" json:"disabled,omitempty"`
+	Parallel bool           `yaml:"parallel,omitempty" toml:"parallel,omitempty" json:"parallel,omitempty"`
+	Workers  int            `yaml:"workers,omitempty" toml:"workers,omitempty" json:"workers,omitempty"`
+	Commands []*HookCommand `yaml:"commands,omitempty" toml:"commands,omitempty" json:"commands,omitempty"`
+}
 
 type HuskyConfig struct {
-	DefaultPermissions os.FileMode
-	HooksTemplatesDir  string
-	DefaultHooks       map[string]string
-	BackupEnabled      bool
-	LogLevel           string
+	DefaultPermissions FileMode                `yaml:"permissions" toml:"permissions" json:"permissions"`
+	HooksTemplatesDir  string                  `yaml:"templates_dir" toml:"templates_dir" json:"templates_dir"`
+	BackupEnabled      bool                    `yaml:"backup" toml:"backup" json:"backup"`
+	LogLevel           string                  `yaml:"log_level" toml:"log_level" json:"log_level"`
+	InstallStrategy    string                  `yaml:"install_strategy,omitempty" toml:"install_strategy,omitempty" json:"install_strategy,omitempty"`
+	Packages           []string                `yaml:"packages,omitempty" toml:"packages,omitempty" json:"packages,omitempty"`
+	DiscoverPackages   bool                    `yaml:"discover_packages" toml:"discover_packages" json:"discover_packages"`
+	CI                 string                  `yaml:"ci,omitempty" toml:"ci,omitempty" json:"ci,omitempty"`
+	TemplateSources    []TemplateSource        `yaml:"template_sources,omitempty" toml:"template_sources,omitempty" json:"template_sources,omitempty"`
+	CommitMsg          *CommitMsgConfig        `yaml:"commit_msg,omitempty" toml:"commit_msg,omitempty" json:"commit_msg,omitempty"`
+	PrepareCommitMsg   *PrepareCommitMsgConfig `yaml:"prepare_commit_msg,omitempty" toml:"prepare_commit_msg,omitempty" json:"prepare_commit_msg,omitempty"`
+	Hooks              map[string]*HookConfig  `yaml:"hooks" toml:"hooks" json:"hooks"`
+
+	path string // file the config was loaded from, empty if none
 }
 
 func NewDefaultConfig() *HuskyConfig {
 	return &HuskyConfig{
 		DefaultPermissions: 0755,
 		HooksTemplatesDir:  "templates",
-		DefaultHooks: map[string]string{
-			"pre-commit":  defaultPreCommitTemplate,
-			"pre-push":    defaultPrePushTemplate,
-			"post-commit": defaultPostCommitTemplate,
-		},
-		BackupEnabled: true,
-		LogLevel:      "info",
+		BackupEnabled:      true,
+		LogLevel:           "info",
+		DiscoverPackages:   true,
+		Hooks:              map[string]*HookConfig{},
+	}
+}
+
+// exported functions
+var (
+	LoadConfig = loadConfig
+	SaveConfig = saveConfig
+)
+
+// Path returns the file the config was loaded from, or the default location if it was never saved
+func (c *HuskyConfig) Path() string {
+	if c.path != "" {
+		return c.path
+	}
+	return filepath.Join(tools.GetHuskyDir(true), ConfigFileNames[0])
+}
+
+// Loaded reports whether the config was read from a file
+func (c *HuskyConfig) Loaded() bool {
+	return c.path != ""
+}
+
+// Validate checks the config for invalid hooks, commands and settings
+func (c *HuskyConfig) Validate() error {
+	if _, err := tools.ParseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if c.InstallStrategy != "" && !isValidStrategy(c.InstallStrategy) {
+		return fmt.Errorf("invalid install strategy '%s'", c.InstallStrategy)
+	}
+	if c.CI != "" && !isValidCIMode(c.CI) {
+		return fmt.Errorf("invalid ci mode '%s', use one of: %s", c.CI, strings.Join(CIModes, ", "))
+	}
+	for _, dir := range c.Packages {
+		if filepath.IsAbs(dir) || strings.HasPrefix(filepath.Clean(dir), "..") {
+			return fmt.Errorf("package '%s' must be a directory inside the repository", dir)
+		}
+	}
+	if c.CommitMsg != nil {
+		if err := c.CommitMsg.Validate(); err != nil {
+			return fmt.Errorf("commit_msg: %w", err)
+		}
+	}
+	if c.PrepareCommitMsg != nil {
+		if err := c.PrepareCommitMsg.Validate(); err != nil {
+			return fmt.Errorf("prepare_commit_msg: %w", err)
+		}
+	}
+	sources := map[string]bool{}
+	for _, source := range c.TemplateSources {
+		if err := source.Validate(); err != nil {
+			return err
+		}
+		if sources[source.name()] {
+			return fmt.Errorf("duplicate template source '%s'", source.name())
+		}
+		sources[source.name()] = true
+	}
+	for name, hook := range c.Hooks {
+		if !tools.IsValidHook(name) {
+			return fmt.Errorf("invalid hook '%s'", name)
+		}
+		if hook == nil {
+			continue
+		}
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("hook '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the commands of the hook and their dependencies
+func (h *HookConfig) Validate() error {
+	if h.Workers < 0 {
+		return errors.New("workers cannot be negative")
+	}
+
+	names := map[string]*HookCommand{}
+	for i, command := range h.Commands {
+		if command == nil || (strings.TrimSpace(command.Run) == "" && command.Builtin == "") {
+			return fmt.Errorf("command #%d is empty", i+1)
+		}
+		if command.Builtin != "" {
+			if command.Run != "" {
+				return fmt.Errorf("command #%d declares both run and builtin", i+1)
+			}
+			if err := validateBuiltin(command.Builtin, command.With); err != nil {
+				return fmt.Errorf("command #%d: %w", i+1, err)
+			}
+		} else if len(command.With) > 0 {
+			return fmt.Errorf("command #%d declares options but no builtin", i+1)
+		}
+		if command.Name == "" {
+			if len(command.Needs) > 0 {
+				return fmt.Errorf("command #%d declares needs but has no name", i+1)
+			}
+			continue
+		}
+		if _, ok := names[command.Name]; ok {
+			return fmt.Errorf("duplicate command name '%s'", command.Name)
+		}
+		names[command.Name] = command
+	}
+
+	for _, command := range h.Commands {
+		for _, need := range command.Needs {
+			if _, ok := names[need]; !ok {
+				return fmt.Errorf("command '%s' needs unknown command '%s'", command.Name, need)
+			}
+		}
+	}
+
+	// detect dependency cycles with a depth-first search
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at command '%s'", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, need := range names[name].Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MaxWorkers returns how many commands of the hook may run at the same time
+func (h *HookConfig) MaxWorkers() int {
+	if !h.Parallel {
+		return 1
+	}
+	if h.Workers > 0 {
+		return h.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// Hook returns the declaration of a hook, creating it if needed
+func (c *HuskyConfig) Hook(name string) *HookConfig {
+	if c.Hooks == nil {
+		c.Hooks = map[string]*HookConfig{}
+	}
+	hook, ok := c.Hooks[name]
+	if !ok || hook == nil {
+		hook = &HookConfig{}
+		c.Hooks[name] = hook
+	}
+	return hook
+}
+
+// findConfigFile returns the path of the first config file found in the husky directory
+func findConfigFile(huskyDir string) (string, error) {
+	for _, name := range ConfigFileNames {
+		file := filepath.Join(huskyDir, name)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", ErrConfigNotFound
+}
+
+// loadConfig reads the config file from .husky, falling back to the defaults when there is none
+func loadConfig() (*HuskyConfig, error) {
+	return loadConfigFrom(tools.GetHuskyDir(true))
+}
+
+// loadConfigFrom reads the config file from the given husky directory
+func loadConfigFrom(huskyDir string) (*HuskyConfig, error) {
+	config := NewDefaultConfig()
+
+	file, err := findConfigFile(huskyDir)
+	if errors.Is(err, ErrConfigNotFound) {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decodeConfig(file, data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", file, err)
+	}
+
+	config.path = file
+	return config, nil
+}
+
+// saveConfig writes the config to the file it was loaded from, or to .husky/husky.yaml
+func saveConfig(config *HuskyConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	file := config.Path()
+	data, err := encodeConfig(file, config)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), os.FileMode(config.DefaultPermissions)); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+
+	config.path = file
+	return nil
+}
+
+// decodeConfig decodes data according to the file extension
+func decodeConfig(file string, data []byte, config *HuskyConfig) error {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, config)
+	case ".toml":
+		return toml.Unmarshal(data, config)
+	case ".json":
+		return json.Unmarshal(data, config)
 	}
+	return fmt.Errorf("unsupported config format '%s'", filepath.Ext(file))
 }
 
-func LoadTemplates() map[string]*HookTemplate {
-	return map[string]*HookTemplate{
-		"pre-commit":  {Name: "pre-commit", Content: defaultPreCommitTemplate},
-		"pre-push":    {Name: "pre-push", Content: defaultPrePushTemplate},
-		"post-commit": {Name: "post-commit", Content: defaultPostCommitTemplate},
+// encodeConfig encodes the config according to the file extension
+func encodeConfig(file string, config *HuskyConfig) ([]byte, error) {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(config); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ".toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ".json":
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
 	}
+	return nil, fmt.Errorf("unsupported config format '%s'", filepath.Ext(file))
 }
@@ -0,0 +1,198 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.MkdirAll(".husky", 0755)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "YAML config",
+			file: "husky.yaml",
+			content: `permissions: "0700"
+backup: false
+log_level: debug
+hooks:
+  pre-commit:
+    commands:
+      - run: go test ./...
+`,
+		},
+		{
+			name: "TOML config",
+			file: "husky.toml",
+			content: `permissions = "0700"
+backup = false
+log_level = "debug"
+
+[[hooks.pre-commit.commands]]
+run = "go test ./..."
+`,
+		},
+		{
+			name: "JSON config",
+			file: "husky.json",
+			content: `{
+  "permissions": "0700",
+  "backup": false,
+  "log_level": "debug",
+  "hooks": {"pre-commit": {"commands": [{"run": "go test ./..."}]}}
+}`,
+		},
+		{
+			name: "Invalid hook",
+			file: "husky.yaml",
+			content: `hooks:
+  hook-invalido:
+    commands:
+      - run: echo 'teste'
+`,
+			wantErr: true,
+		},
+		{
+			name: "Empty command",
+			file: "husky.yaml",
+			content: `hooks:
+  pre-commit:
+    commands:
+      - run: ""
+`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid log level",
+			file:    "husky.yaml",
+			content: "log_level: verbose\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(".husky", tt.file)
+			assert.NoError(t, os.WriteFile(file, []byte(tt.content), 0644))
+			defer os.Remove(file)
+
+			config, err := LoadConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, config.Loaded())
+			assert.Equal(t, file, config.Path())
+			assert.Equal(t, FileMode(0700), config.DefaultPermissions)
+			assert.False(t, config.BackupEnabled)
+			assert.Equal(t, "debug", config.LogLevel)
+			assert.Equal(t, "templates", config.HooksTemplatesDir)
+			if assert.Contains(t, config.Hooks, "pre-commit") {
+				assert.Equal(t, "go test ./...", config.Hooks["pre-commit"].Commands[0].Run)
+			}
+		})
+	}
+
+	t.Run("Missing config falls back to defaults", func(t *testing.T) {
+		config, err := LoadConfig()
+		assert.NoError(t, err)
+		assert.False(t, config.Loaded())
+		assert.Equal(t, NewDefaultConfig().DefaultPermissions, config.DefaultPermissions)
+	})
+}
+
+func TestSaveConfig(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	for _, name := range ConfigFileNames {
+		t.Run(name, func(t *testing.T) {
+			config := NewDefaultConfig()
+			config.path = filepath.Join(".husky", name)
+			config.Hook("pre-push").Commands = []*HookCommand{{Run: "go vet ./..."}}
+
+			assert.NoError(t, SaveConfig(config))
+			defer os.Remove(config.Path())
+
+			loaded, err := LoadConfig()
+			assert.NoError(t, err)
+			assert.Equal(t, config.Path(), loaded.Path())
+			assert.Equal(t, config.DefaultPermissions, loaded.DefaultPermissions)
+			assert.Equal(t, config.Hooks, loaded.Hooks)
+		})
+	}
+}
+
+func TestHookConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    HookConfig
+		wantErr string
+	}{
+		{
+			name: "Valid dependencies",
+			hook: HookConfig{Commands: []*HookCommand{
+				{Name: "vet", Run: "go vet ./..."},
+				{Name: "test", Run: "go test ./...", Needs: []string{"vet"}},
+			}},
+		},
+		{
+			name: "Duplicate name",
+			hook: HookConfig{Commands: []*HookCommand{
+				{Name: "vet", Run: "go vet ./..."},
+				{Name: "vet", Run: "go test ./..."},
+			}},
+			wantErr: "duplicate command name 'vet'",
+		},
+		{
+			name: "Unknown dependency",
+			hook: HookConfig{Commands: []*HookCommand{
+				{Name: "test", Run: "go test ./...", Needs: []string{"lint"}},
+			}},
+			wantErr: "needs unknown command 'lint'",
+		},
+		{
+			name: "Dependency cycle",
+			hook: HookConfig{Commands: []*HookCommand{
+				{Name: "a", Run: "true", Needs: []string{"b"}},
+				{Name: "b", Run: "true", Needs: []string{"a"}},
+			}},
+			wantErr: "dependency cycle",
+		},
+		{
+			name:    "Negative workers",
+			hook:    HookConfig{Parallel: true, Workers: -1},
+			wantErr: "workers cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hook.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,458 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// Severity is how much a doctor finding affects the hooks
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"    // worth knowing, hooks still work
+	SeverityWarning Severity = "warning" // hooks may not run as expected
+	SeverityError   Severity = "error"   // hooks cannot run
+)
+
+// staleBackupAge is the age after which backups of replaced hooks are reported
+const staleBackupAge = 90 * 24 * time.Hour
+
+// minGitVersion is the first git release supporting core.hooksPath
+var minGitVersion = [2]int{2, 9}
+
+// Finding is a problem found by the doctor command
+type Finding struct {
+	Check    string   // check reporting the problem
+	Severity Severity // impact of the problem
+	Message  string   // what is wrong
+	Fix      string   // how to fix it
+	Fixed    bool     // whether --fix applied the fix
+
+	apply func() error // safe fix applied by --fix, nil when it must be done by hand
+}
+
+// Fixable reports whether --fix can apply the fix
+func (f *Finding) Fixable() bool {
+	return f.apply != nil
+}
+
+// DoctorOptions are the options for the doctor command
+type DoctorOptions struct {
+	Fix    bool      // apply the safe fixes
+	Stdout io.Writer // defaults to os.Stdout
+}
+
+// DoctorReport is the outcome of the doctor command
+type DoctorReport struct {
+	Findings []*Finding
+}
+
+// Failed reports whether an error remains unfixed
+func (r *DoctorReport) Failed() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityError && !finding.Fixed {
+			return true
+		}
+	}
+	return false
+}
+
+// exported functions
+var (
+	Doctor = doctor
+)
+
+// doctor checks everything that commonly prevents hooks from running, optionally fixing the safe problems
+func doctor(opts DoctorOptions) (*DoctorReport, error) {
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+
+	report := &DoctorReport{}
+	add := func(f *Finding) {
+		report.Findings = append(report.Findings, f)
+	}
+
+	for _, check := range []func() []*Finding{checkGit, checkRepository} {
+		findings := check()
+		for _, f := range findings {
+			add(f)
+		}
+		// the other checks need git and a repository
+		if len(findings) > 0 && findings[0].Severity == SeverityError {
+			return report, printReport(opts, report)
+		}
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		add(&Finding{
+			Check:    "config",
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Fix:      "fix the config file",
+		})
+		config = NewDefaultConfig()
+	}
+
+	for _, check := range []func(*HuskyConfig) []*Finding{checkHooksPath, checkHuskyHooks, checkInstalledHooks, checkLeftovers} {
+		for _, f := range check(config) {
+			add(f)
+		}
+	}
+
+	if opts.Fix {
+		for _, f := range report.Findings {
+			if !f.Fixable() {
+				continue
+			}
+			if err := f.apply(); err != nil {
+				f.Message += fmt.Sprintf(" (fix failed: %v)", err)
+				continue
+			}
+			f.Fixed = true
+		}
+	}
+
+	return report, printReport(opts, report)
+}
+
+// printReport prints the findings with their fix
+func printReport(opts DoctorOptions, report *DoctorReport) error {
+	var sb strings.Builder
+	sb.WriteString(" husky doctor\n\n")
+	if len(report.Findings) == 0 {
+		sb.WriteString("  ✅ No problems found\n")
+	}
+
+	fixable := 0
+	for _, f := range report.Findings {
+		icon := map[Severity]string{SeverityInfo: "ℹ️ ", SeverityWarning: "⚠️ ", SeverityError: "❌"}[f.Severity]
+		if f.Fixed {
+			icon = "✅"
+		}
+		sb.WriteString(fmt.Sprintf("  %s [%s] %s: %s\n", icon, f.Severity, f.Check, f.Message))
+		switch {
+		case f.Fixed:
+			sb.WriteString(fmt.Sprintf("       fixed: %s\n", f.Fix))
+		case f.Fix != "":
+			sb.WriteString(fmt.Sprintf("       fix: %s\n", f.Fix))
+		}
+		if f.Fixable() && !f.Fixed {
+			fixable++
+		}
+	}
+
+	if fixable > 0 && !opts.Fix {
+		sb.WriteString(fmt.Sprintf("\n  Run 'husky doctor --fix' to apply %d safe fix(es)\n", fixable))
+	}
+
+	_, err := fmt.Fprint(opts.Stdout, sb.String())
+	return err
+}
+
+// gitVersionPattern extracts the version from the output of git --version
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// checkGit checks that git is on the PATH and recent enough
+func checkGit() []*Finding {
+	if _, err := exec.LookPath("git"); err != nil {
+		return []*Finding{{
+			Check:    "git",
+			Severity: SeverityError,
+			Message:  "git not found on PATH",
+			Fix:      "install git and add it to the PATH",
+		}}
+	}
+
+	out, err := tools.Git("--version")
+	if err != nil {
+		return []*Finding{{Check: "git", Severity: SeverityError, Message: err.Error(), Fix: "reinstall git"}}
+	}
+
+	match := gitVersionPattern.FindStringSubmatch(out)
+	if match == nil {
+		return []*Finding{{
+			Check:    "git",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("cannot parse git version %q", strings.TrimSpace(out)),
+		}}
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	if major < minGitVersion[0] || (major == minGitVersion[0] && minor < minGitVersion[1]) {
+		return []*Finding{{
+			Check:    "git",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("git %d.%d is older than %d.%d, core.hooksPath and some hooks are not supported", major, minor, minGitVersion[0], minGitVersion[1]),
+			Fix:      "upgrade git",
+		}}
+	}
+	return nil
+}
+
+// checkRepository checks that the repository and husky can be found
+func checkRepository() []*Finding {
+	if _, err := tools.FindRepository(); err != nil {
+		return []*Finding{{
+			Check:    "repository",
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Fix:      "run husky inside a git repository",
+		}}
+	}
+	if !tools.HuskyExists() {
+		return []*Finding{{
+			Check:    "repository",
+			Severity: SeverityWarning,
+			Message:  "husky is not initialized in the repository",
+			Fix:      "run 'husky init'",
+		}}
+	}
+	return nil
+}
+
+// checkHooksPath checks that core.hooksPath does not send git to another hooks directory
+func checkHooksPath(config *HuskyConfig) []*Finding {
+	hooksPath := tools.GetGitConfig("core.hooksPath")
+	huskyHooksDir := tools.GetHuskyHooksDir(true)
+	if hooksPath == "" || isHuskyHooksPath(huskyHooksDir) {
+		return nil
+	}
+
+	return []*Finding{{
+		Check:    "core.hooksPath",
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("core.hooksPath is set to %s, git ignores the hooks installed by husky", hooksPath),
+		Fix:      "run 'git config --unset core.hooksPath' (or --global) if no other tool needs it, then 'husky install'",
+	}}
+}
+
+// checkHuskyHooks checks the scripts of .husky/hooks: executable bit, line endings and interpreter
+func checkHuskyHooks(config *HuskyConfig) []*Finding {
+	findings := []*Finding{}
+	dir := tools.GetHuskyHooksDir(true)
+	for _, name := range hookFiles(dir) {
+		file := filepath.Join(dir, name)
+		findings = append(findings, checkScript("hooks", file, os.FileMode(config.DefaultPermissions))...)
+	}
+	return findings
+}
+
+// checkScript checks a hook script can be executed by git
+func checkScript(check, file string, mode os.FileMode) []*Finding {
+	findings := []*Finding{}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil
+	}
+
+	if info.Mode()&0111 == 0 {
+		findings = append(findings, &Finding{
+			Check:    check,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s is not executable", file),
+			Fix:      fmt.Sprintf("chmod %04o %s", mode, file),
+			apply:    func() error { return os.Chmod(file, mode) },
+		})
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return append(findings, &Finding{Check: check, Severity: SeverityError, Message: err.Error()})
+	}
+
+	if bytes.Contains(content, []byte("\r\n")) {
+		findings = append(findings, &Finding{
+			Check:    check,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s has CRLF line endings", file),
+			Fix:      "convert the line endings to LF",
+			apply: func() error {
+				return os.WriteFile(file, bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), info.Mode().Perm())
+			},
+		})
+	}
+
+	line, _ := bufio.NewReader(bytes.NewReader(content)).ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "#!") {
+		return append(findings, &Finding{
+			Check:    check,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s has no shebang", file),
+			Fix:      "add '#!/bin/sh' as the first line",
+		})
+	}
+
+	if interpreter := shebangInterpreter(line); interpreter != "" {
+		if _, err := exec.LookPath(interpreter); err != nil {
+			findings = append(findings, &Finding{
+				Check:    check,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("interpreter %s of %s not found", interpreter, file),
+				Fix:      fmt.Sprintf("install %s or change the shebang", interpreter),
+			})
+		}
+	}
+
+	return findings
+}
+
+// shebangInterpreter returns the program run by a shebang line, resolving /usr/bin/env
+func shebangInterpreter(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" {
+		for _, field := range fields[1:] {
+			// skip env options and variable assignments
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				return field
+			}
+		}
+		return ""
+	}
+	return fields[0]
+}
+
+// checkInstalledHooks checks that the husky hooks are installed and in sync with their source
+func checkInstalledHooks(config *HuskyConfig) []*Finding {
+	huskyHooksDir := tools.GetHuskyHooksDir(true)
+	gitHooksDir := tools.GetGitHooksDir(true)
+	if isHuskyHooksPath(huskyHooksDir) {
+		return nil
+	}
+
+	strategy := config.InstallStrategy
+	if strategy == "" || strategy == StrategyHooksPath {
+		strategy = StrategyLink
+	}
+
+	findings := []*Finding{}
+	for _, name := range hookFiles(huskyHooksDir) {
+		huskyHook := filepath.Join(huskyHooksDir, name)
+		gitHook := filepath.Join(gitHooksDir, name)
+
+		if _, err := os.Lstat(gitHook); os.IsNotExist(err) {
+			findings = append(findings, &Finding{
+				Check:    "install",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s is not installed", name),
+				Fix:      "run 'husky install'",
+			})
+			continue
+		}
+		if !isManagedHook(gitHook, huskyHook) {
+			findings = append(findings, &Finding{
+				Check:    "install",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s is not managed by husky", gitHook),
+				Fix:      "run 'husky install --chain' to run it before the husky hook, or 'husky install --force' to replace it",
+			})
+			continue
+		}
+
+		// hooks sharing the file of the husky hook were checked with it
+		if !sameFile(gitHook, huskyHook) {
+			findings = append(findings, checkScript("install", gitHook, 0755)...)
+		}
+		if drifted(gitHook, huskyHook) {
+			findings = append(findings, &Finding{
+				Check:    "install",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s has drifted from %s", gitHook, huskyHook),
+				Fix:      fmt.Sprintf("reinstall it with the '%s' strategy", strategy),
+				apply: func() error {
+					if err := os.Remove(gitHook); err != nil {
+						return err
+					}
+					_, err := installHookFile(strategy, huskyHook, gitHook)
+					return err
+				},
+			})
+		}
+	}
+
+	return findings
+}
+
+// drifted reports whether a hook installed by husky no longer matches its source,
+// e.g. a hard link broken by an editor saving a new file. Chain wrappers and
+// symlinks always run the current source
+func drifted(gitHook, huskyHook string) bool {
+	if info, err := os.Lstat(gitHook); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+	content, err := os.ReadFile(gitHook)
+	if err != nil || bytes.Contains(content, []byte(chainMarker)) {
+		return false
+	}
+
+	if _, err := os.Stat(huskyHook); err != nil || sameFile(gitHook, huskyHook) {
+		return false
+	}
+	huskyContent, err := os.ReadFile(huskyHook)
+	return err == nil && !bytes.Equal(content, huskyContent)
+}
+
+// sameFile reports whether both paths refer to the same file, following symlinks
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	return err == nil && os.SameFile(aInfo, bInfo)
+}
+
+// checkLeftovers reports what previous runs left behind: stale backups and unrestored changes
+func checkLeftovers(config *HuskyConfig) []*Finding {
+	findings := []*Finding{}
+
+	backups, _ := listBackups()
+	for _, backup := range backups {
+		// backups made in the same second have a -N suffix
+		name := filepath.Base(backup)
+		if len(name) > len(backupTimeFormat) {
+			name = name[:len(backupTimeFormat)]
+		}
+		// backup directories are named after the UTC time
+		created, err := time.ParseInLocation(backupTimeFormat, name, time.UTC)
+		if err != nil || time.Since(created) < staleBackupAge {
+			continue
+		}
+		findings = append(findings, &Finding{
+			Check:    "backups",
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("backup %s is older than %d days", backup, int(staleBackupAge.Hours()/24)),
+			Fix:      fmt.Sprintf("remove %s if the hooks it holds are not needed anymore", backup),
+		})
+	}
+
+	if repo, err := tools.FindRepository(); err == nil {
+		patch := filepath.Join(repo.GitDir, "husky", "unstaged.patch")
+		if _, err := os.Stat(patch); err == nil {
+			findings = append(findings, &Finding{
+				Check:    "stage_fixed",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("unstaged changes saved in %s were not restored, hooks with stage_fixed commands will refuse to run", patch),
+				Fix:      fmt.Sprintf("run 'git apply %s' and remove it", patch),
+			})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctor(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - run: go test ./...
+  pre-push:
+    commands:
+      - run: go vet ./...
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, Install(InstallOptions{Quiet: true, Strategy: StrategyCopy}))
+
+	t.Run("Healthy repository", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		report, err := Doctor(DoctorOptions{Stdout: stdout})
+		assert.NoError(t, err)
+		assert.Empty(t, report.Findings)
+		assert.Contains(t, stdout.String(), "No problems found")
+	})
+
+	// break the hooks in every way doctor knows about
+	os.WriteFile(".husky/hooks/commit-msg", []byte("echo no shebang\r\n"), 0644)
+	os.WriteFile(".husky/hooks/post-merge", []byte("#!/usr/bin/env husky-missing-interpreter\n"), 0755)
+	os.Chmod(".husky/hooks/pre-commit", 0644)
+	os.WriteFile(".git/hooks/pre-push", []byte("#!/bin/sh\nexec husky run pre-push \"$@\"\n# edited\n"), 0755)
+	gitCmd(t, "config", "core.hooksPath", "elsewhere")
+
+	t.Run("Findings", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		report, err := Doctor(DoctorOptions{Stdout: stdout})
+		assert.NoError(t, err)
+		assert.True(t, report.Failed())
+
+		messages := []string{}
+		for _, f := range report.Findings {
+			assert.NotEmpty(t, f.Fix, f.Message)
+			messages = append(messages, string(f.Severity)+" "+f.Message)
+		}
+		all := strings.Join(messages, "\n")
+		for _, want := range []string{
+			"error core.hooksPath is set to elsewhere",
+			"error " + filepath.Join(".husky", "hooks", "pre-commit") + " is not executable",
+			"commit-msg has CRLF line endings",
+			"commit-msg has no shebang",
+			"interpreter husky-missing-interpreter",
+			"warning commit-msg is not installed",
+			"pre-push has drifted",
+		} {
+			assert.Contains(t, all, want)
+		}
+		assert.Contains(t, stdout.String(), "husky doctor --fix")
+	})
+
+	t.Run("Fix applies the safe fixes", func(t *testing.T) {
+		report, err := Doctor(DoctorOptions{Fix: true, Stdout: new(bytes.Buffer)})
+		assert.NoError(t, err)
+
+		fixed := 0
+		for _, f := range report.Findings {
+			if f.Fixed {
+				fixed++
+			}
+		}
+		// two chmod, the line endings and the drifted hook
+		assert.Equal(t, 4, fixed)
+
+		info, _ := os.Stat(".husky/hooks/pre-commit")
+		assert.NotZero(t, info.Mode()&0111)
+		content, _ := os.ReadFile(".husky/hooks/commit-msg")
+		assert.NotContains(t, string(content), "\r\n")
+		pushHook, _ := os.ReadFile(".git/hooks/pre-push")
+		huskyHook, _ := os.ReadFile(".husky/hooks/pre-push")
+		assert.Equal(t, string(huskyHook), string(pushHook))
+
+		// core.hooksPath may belong to another tool, it is never changed
+		assert.Equal(t, "elsewhere\n", gitCmd(t, "config", "core.hooksPath"))
+	})
+}
+
+func TestStaleBackups(t *testing.T) {
+	initGitRepo(t)
+
+	// far from UTC, so parsing the names in the local time zone would be off by 14 hours
+	local := time.Local
+	time.Local = time.FixedZone("UTC+14", 14*60*60)
+	defer func() { time.Local = local }()
+
+	now := time.Now().UTC()
+	recent := now.Add(-staleBackupAge + 2*time.Hour).Format(backupTimeFormat)
+	stale := now.Add(-staleBackupAge - 2*time.Hour).Format(backupTimeFormat)
+	for _, name := range []string{recent, stale + "-1"} {
+		os.MkdirAll(filepath.Join(".husky", "_backup", name), 0755)
+	}
+
+	findings := checkLeftovers(NewDefaultConfig())
+	if assert.Len(t, findings, 1) {
+		assert.Contains(t, findings[0].Message, stale+"-1")
+	}
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/sh":                    "/bin/sh",
+		"#!/usr/bin/env node":          "node",
+		"#!/usr/bin/env -S python3 -u": "python3",
+		"#!/usr/bin/env FOO=1 bash":    "bash",
+		"#!":                           "",
+	}
+	for line, want := range tests {
+		assert.Equal(t, want, shebangInterpreter(line), line)
+	}
+}
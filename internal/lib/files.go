@@ -0,0 +1,212 @@
+package lib
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// File placeholders expanded in the commands
+const (
+	placeholderStagedFiles = "{staged_files}"
+	placeholderAllFiles    = "{all_files}"
+	placeholderPushFiles   = "{push_files}"
+)
+
+// fileSets lazily loads the file lists a hook run refers to
+type fileSets struct {
+	input   *HookInput
+	lists   map[string][]string
+	removed map[string][]string
+}
+
+func newFileSets(input *HookInput) *fileSets {
+	return &fileSets{input: input, lists: map[string][]string{}, removed: map[string][]string{}}
+}
+
+// get returns the files of the set referred by the placeholder
+func (f *fileSets) get(placeholder string) ([]string, error) {
+	if files, ok := f.lists[placeholder]; ok {
+		return files, nil
+	}
+
+	var files []string
+	var err error
+	switch placeholder {
+	case placeholderStagedFiles:
+		files, err = tools.StagedFiles()
+	case placeholderAllFiles:
+		files, err = tools.AllFiles()
+	case placeholderPushFiles:
+		files, err = f.input.pushFiles()
+	default:
+		return nil, fmt.Errorf("unknown placeholder %s", placeholder)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.lists[placeholder] = files
+	return files, nil
+}
+
+// getRemoved returns the files deleted or renamed away in the set referred by the placeholder,
+// none for all the files
+func (f *fileSets) getRemoved(placeholder string) ([]string, error) {
+	if files, ok := f.removed[placeholder]; ok {
+		return files, nil
+	}
+
+	var files []string
+	var err error
+	switch placeholder {
+	case placeholderStagedFiles:
+		files, err = tools.StagedRemovedFiles()
+	case placeholderPushFiles:
+		files, err = f.input.removedFiles()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.removed[placeholder] = files
+	return files, nil
+}
+
+// defaultFilesPlaceholder returns the file set a hook filters by when the command uses no placeholder
+func defaultFilesPlaceholder(hook string) string {
+	switch hook {
+	case "pre-commit":
+		return placeholderStagedFiles
+	case "pre-push":
+		return placeholderPushFiles
+	}
+	return placeholderAllFiles
+}
+
+// filesDescription describes a file set for the skip messages
+func filesDescription(placeholder string) string {
+	return strings.ReplaceAll(strings.Trim(placeholder, "{}"), "_", " ")
+}
+
+// filterFiles returns the files matching any of the globs and none of the exclude patterns
+func filterFiles(files, globs, exclude []string) []string {
+	filtered := []string{}
+	for _, file := range files {
+		if len(globs) > 0 && !matchAny(globs, file) {
+			continue
+		}
+		if matchAny(exclude, file) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered
+}
+
+func matchAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if tools.MatchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes the files to be safely used as arguments of a shell command
+func shellQuote(files []string) string {
+	quoted := make([]string, len(files))
+	for i, file := range files {
+		quoted[i] = "'" + strings.ReplaceAll(file, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// task is a command ready to be executed by the runner
+type task struct {
+	command    *HookCommand
+	config     *HuskyConfig // config the command is declared in
+	name       string       // name shown in the output, prefixed by the package directory
+	dir        string       // directory the command runs in, empty for the root of the repository
+	run        string       // command line with the placeholders expanded
+	files      []string     // files matched by the command filters, relative to the root of the repository
+	removed    []string     // files removed by the changes and matched by the filters, for the builtins asking for them
+	skipReason string       // why the command must not run, empty if it must
+}
+
+// prepareTask filters the files of a command and expands its placeholders,
+// skipping the command when it works on files and none of them match.
+// Commands of a package only see the files below it, relative to its directory
+func prepareTask(hookName string, command *HookCommand, files *fileSets, pkg *Package) (*task, error) {
+	t := &task{command: command, config: pkg.Config, name: pkg.label(command.DisplayName()), dir: filepath.FromSlash(pkg.Dir), run: command.Run}
+
+	placeholders := []string{}
+	for _, placeholder := range []string{placeholderStagedFiles, placeholderAllFiles, placeholderPushFiles} {
+		if strings.Contains(command.Run, placeholder) {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+
+	// builtins working on files only see those files, unless the step has its own globs
+	globs := command.Glob
+	b, isBuiltin := lookupBuiltin(command.Builtin)
+	if isBuiltin && len(globs) == 0 {
+		globs = b.files
+	}
+
+	if len(placeholders) == 0 {
+		if len(globs) == 0 && len(command.Exclude) == 0 {
+			return t, nil
+		}
+		placeholders = append(placeholders, defaultFilesPlaceholder(hookName))
+	}
+
+	// match returns the files of the package matching the filters, relative to its directory
+	match := func(all []string) []string {
+		relative := []string{}
+		for _, file := range all {
+			if pkg.contains(file) {
+				relative = append(relative, pkg.rel(file))
+			}
+		}
+		return filterFiles(relative, globs, command.Exclude)
+	}
+
+	for _, placeholder := range placeholders {
+		all, err := files.get(placeholder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", filesDescription(placeholder), err)
+		}
+		matched := match(all)
+
+		removed := []string{}
+		if isBuiltin && b.removed {
+			all, err := files.getRemoved(placeholder)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the removed %s: %w", filesDescription(placeholder), err)
+			}
+			removed = match(all)
+		}
+
+		if len(matched) == 0 && len(removed) == 0 {
+			t.skipReason = fmt.Sprintf("no %s match", filesDescription(placeholder))
+			if len(globs) > 0 {
+				t.skipReason += " " + strings.Join(globs, ", ")
+			}
+			return t, nil
+		}
+
+		for _, file := range matched {
+			t.files = append(t.files, path.Join(pkg.Dir, file))
+		}
+		for _, file := range removed {
+			t.removed = append(t.removed, path.Join(pkg.Dir, file))
+		}
+		t.run = strings.ReplaceAll(t.run, placeholder, shellQuote(matched))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// initGitRepo creates a git repository in a temporary directory and changes to it
+func initGitRepo(t *testing.T) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	t.Cleanup(func() { os.Chdir(originalWd) })
+
+	gitCmd(t, "init", "-q")
+	gitCmd(t, "config", "user.email", "husky@example.com")
+	gitCmd(t, "config", "user.name", "Husky")
+	gitCmd(t, "config", "commit.gpgsign", "false")
+}
+
+// gitCmd runs a git command in the current directory, failing the test on error
+func gitCmd(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func TestRunFileFilters(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - name: gofmt
+        run: echo {staged_files}
+        glob: ["*.go"]
+        exclude: ["vendor/**"]
+      - name: rust
+        run: echo {staged_files}
+        glob: ["*.rs"]
+      - name: docs
+        run: echo docs changed
+        glob: ["docs/**"]
+      - name: all
+        run: echo {all_files}
+        glob: ["*.md"]
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+	os.MkdirAll("vendor/lib", 0755)
+	os.MkdirAll("cmd", 0755)
+	os.WriteFile("README.md", []byte("# test\n"), 0644)
+	gitCmd(t, "add", "README.md")
+	gitCmd(t, "commit", "-q", "-m", "initial")
+
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	os.WriteFile("cmd/it's.go", []byte("package cmd\n"), 0644)
+	os.WriteFile("vendor/lib/lib.go", []byte("package lib\n"), 0644)
+	os.WriteFile("unstaged.go", []byte("package main\n"), 0644)
+	gitCmd(t, "add", "main.go", "cmd/it's.go", "vendor/lib/lib.go")
+
+	stdout := new(bytes.Buffer)
+	result, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: stdout, Quiet: true})
+	assert.NoError(t, err)
+	assert.False(t, result.Failed())
+
+	assert.Contains(t, stdout.String(), "[gofmt] cmd/it's.go main.go\n")
+	assert.NotContains(t, stdout.String(), "unstaged.go")
+	assert.NotContains(t, stdout.String(), "vendor")
+	assert.Contains(t, stdout.String(), "[all] README.md\n")
+
+	assert.True(t, result.Commands[1].Skipped)
+	assert.Equal(t, "no staged files match *.rs", result.Commands[1].SkipReason)
+	assert.True(t, result.Commands[2].Skipped)
+}
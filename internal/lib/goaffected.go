@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// goPackage is the part of the go list -json output the affected packages are computed from
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	DepOnly      bool     // listed only as a dependency of the packages matching the pattern
+	Deps         []string // transitive dependencies
+	TestImports  []string
+	XTestImports []string
+}
+
+// listGoPackages lists the packages of a module with their dependencies
+func listGoPackages(dir string) ([]goPackage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-e", "-deps", "-json", "./...")
+	cmd.Dir = filepath.FromSlash(dir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list failed in %s: %s", dir, strings.TrimSpace(stderr.String()))
+	}
+
+	packages := []goPackage{}
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg goPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if !pkg.DepOnly {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// affectedPackages returns the packages of the module affected by the changes: the changed
+// packages, the packages depending on them, and the packages whose tests import any of those
+func affectedPackages(ctx *builtinContext, module *goModule) ([]string, error) {
+	if len(module.packages) == 1 && module.packages[0] == "./..." {
+		return module.packages, nil
+	}
+
+	root, err := resolvedPath(module.dir)
+	if err != nil {
+		return nil, err
+	}
+	packages, err := listGoPackages(module.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the changed packages, by import path
+	patterns := map[string]string{}
+	for _, pkg := range packages {
+		dir, err := resolvedPath(pkg.Dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		pattern := "."
+		if rel != "." {
+			pattern = "./" + filepath.ToSlash(rel)
+		}
+		patterns[pkg.ImportPath] = pattern
+	}
+	changed := map[string]bool{}
+	for _, pkg := range packages {
+		if contains(module.packages, patterns[pkg.ImportPath]) {
+			changed[pkg.ImportPath] = true
+		}
+	}
+	// a package whose files were all removed is not listed anymore, its importers still depend on it
+	if modulePath := goModulePath(module.dir); modulePath != "" {
+		for _, pattern := range module.packages {
+			changed[path.Join(modulePath, pattern)] = true
+		}
+	}
+	if len(changed) == 0 {
+		return existingPackages(module), nil
+	}
+
+	// Deps is transitive, one pass finds the reverse dependencies
+	affected := map[string]bool{}
+	for _, pkg := range packages {
+		if changed[pkg.ImportPath] || anyIn(pkg.Deps, changed) {
+			affected[pkg.ImportPath] = true
+		}
+	}
+	// tests are not part of Deps, a test importing an affected package is affected too
+	for _, pkg := range packages {
+		if anyIn(pkg.TestImports, affected) || anyIn(pkg.XTestImports, affected) {
+			affected[pkg.ImportPath] = true
+		}
+	}
+
+	result := []string{}
+	for importPath := range affected {
+		if pattern, ok := patterns[importPath]; ok {
+			result = append(result, pattern)
+		}
+	}
+	sort.Strings(result)
+
+	fmt.Fprintf(ctx.stdout, "%d of %d packages affected: %s\n", len(result), len(patterns), strings.Join(result, " "))
+	return result, nil
+}
+
+// anyIn reports whether any of the values is in the set
+func anyIn(values []string, set map[string]bool) bool {
+	for _, value := range values {
+		if set[value] {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvedPath returns the absolute path with the symbolic links resolved, to compare directories
+func resolvedPath(dir string) (string, error) {
+	abs, err := filepath.Abs(filepath.FromSlash(dir))
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffectedPackages(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	initGitRepo(t)
+
+	writeFiles(t, map[string]string{
+		"go.mod":      "module example.com/demo\n\ngo 1.21\n",
+		"a/a.go":      "package a\n\n// A is the base\nfunc A() int { return 1 }\n",
+		"b/b.go":      "package b\n\nimport \"example.com/demo/a\"\n\n// B uses A\nfunc B() int { return a.A() + 1 }\n",
+		"c/c.go":      "package c\n\n// C is independent\nfunc C() int { return 3 }\n",
+		"d/d.go":      "package d\n",
+		"d/d_test.go": "package d_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/demo/b\"\n)\n\nfunc TestD(t *testing.T) {\n\tif b.B() != 2 {\n\t\tt.Fatal(\"unexpected\")\n\t}\n}\n",
+		".husky/husky.yaml": `hooks:
+  pre-push:
+    commands:
+      - builtin: go:test
+        with:
+          affected: "true"
+          args: -count=1
+`,
+	})
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "-q", "-m", "init")
+	base := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+
+	writeFiles(t, map[string]string{"a/a.go": "package a\n\n// A is the base\nfunc A() int { return 1 + 0 }\n"})
+	gitCmd(t, "commit", "-q", "-am", "change a")
+	head := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+
+	push := func(refs string) (string, bool) {
+		t.Helper()
+		stdout := new(bytes.Buffer)
+		result, err := Run(RunOptions{
+			Hook:   "pre-push",
+			Args:   []string{"origin", "git@example.com:demo.git"},
+			Stdin:  strings.NewReader(refs),
+			Stdout: stdout,
+			Stderr: stdout,
+			Quiet:  true,
+		})
+		assert.NoError(t, err)
+		return stdout.String(), result.Failed()
+	}
+
+	out, failed := push(fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", head, base))
+	assert.False(t, failed, out)
+	assert.Contains(t, out, "3 of 4 packages affected: ./a ./b ./d")
+	assert.Contains(t, out, "ok  \texample.com/demo/d")
+	assert.NotContains(t, out, "example.com/demo/c")
+
+	// a change to go.mod affects the whole module
+	writeFiles(t, map[string]string{"go.mod": "module example.com/demo\n\ngo 1.22\n"})
+	gitCmd(t, "commit", "-q", "-am", "bump go")
+	next := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+	out, failed = push(fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", next, head))
+	assert.False(t, failed, out)
+	assert.NotContains(t, out, "packages affected")
+	assert.Contains(t, out, "example.com/demo/c")
+
+	// removing a package nothing imports leaves nothing to test
+	gitCmd(t, "rm", "-r", "-q", "c")
+	gitCmd(t, "commit", "-q", "-m", "remove c")
+	removed := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+	out, failed = push(fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", removed, next))
+	assert.False(t, failed, out)
+	assert.Contains(t, out, "0 of 3 packages affected")
+	assert.Contains(t, out, "no package left to check")
+
+	// a delete-only push checks the packages depending on the removed one
+	gitCmd(t, "rm", "-r", "-q", "a")
+	gitCmd(t, "commit", "-q", "-m", "remove a")
+	last := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+	out, failed = push(fmt.Sprintf("refs/heads/main %s refs/heads/main %s\n", last, removed))
+	assert.True(t, failed, out)
+	assert.Contains(t, out, "2 of 2 packages affected: ./b ./d")
+	assert.NotContains(t, out, "no Go module contains the changed files")
+}
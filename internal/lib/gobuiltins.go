@@ -0,0 +1,457 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goFiles are the files the Go builtins work on
+var goFiles = []string{"*.go", "go.mod", "go.sum"}
+
+// goModule is a Go module with the packages of the changed files
+type goModule struct {
+	dir      string   // directory of go.mod, relative to the root of the repository
+	packages []string // package patterns relative to the module, ./... when go.mod or go.sum changed
+}
+
+// findGoModule returns the directory of the nearest go.mod above a file, false when there
+// is none. The cache remembers the module of each directory visited, empty for none
+func findGoModule(file string, cache map[string]string) (string, bool) {
+	visited := []string{}
+	module, found := "", false
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		if cached, ok := cache[dir]; ok {
+			module, found = cached, cached != ""
+			break
+		}
+		visited = append(visited, dir)
+		if _, err := os.Stat(filepath.Join(filepath.FromSlash(dir), "go.mod")); err == nil {
+			module, found = dir, true
+			break
+		}
+		if dir == "." || dir == "/" {
+			break
+		}
+	}
+
+	for _, dir := range visited {
+		cache[dir] = module
+	}
+	return module, found
+}
+
+// goModules groups the changed files by module, returning the packages to check in each
+// of them and the files outside any module. Directories the go command ignores are skipped
+func goModules(files []string) ([]*goModule, []string) {
+	cache := map[string]string{}
+	packages := map[string]map[string]bool{}
+	outside := []string{}
+
+	for _, file := range files {
+		dir, ok := findGoModule(file, cache)
+		if !ok {
+			outside = append(outside, file)
+			continue
+		}
+		if packages[dir] == nil {
+			packages[dir] = map[string]bool{}
+		}
+
+		rel := strings.TrimPrefix(file, dir+"/")
+		if dir == "." {
+			rel = file
+		}
+		switch {
+		case rel == "go.mod" || rel == "go.sum":
+			packages[dir]["./..."] = true
+		case strings.HasSuffix(rel, ".go") && !ignoredByGo(path.Dir(rel)):
+			packages[dir][path.Dir(rel)] = true
+		}
+	}
+
+	modules := []*goModule{}
+	for dir, set := range packages {
+		module := &goModule{dir: dir}
+		if set["./..."] {
+			module.packages = []string{"./..."}
+		} else {
+			for pkg := range set {
+				if pkg != "." {
+					pkg = "./" + pkg
+				}
+				module.packages = append(module.packages, pkg)
+			}
+			sort.Strings(module.packages)
+		}
+		if len(module.packages) > 0 {
+			modules = append(modules, module)
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].dir < modules[j].dir })
+
+	return modules, outside
+}
+
+// ignoredByGo reports whether the go command ignores a directory: vendor, testdata,
+// and the directories starting with . or _
+func ignoredByGo(dir string) bool {
+	for _, segment := range strings.Split(dir, "/") {
+		if segment == "vendor" || segment == "testdata" || (segment != "." && (strings.HasPrefix(segment, ".") || strings.HasPrefix(segment, "_"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// goPosition matches the file:line references at the start of the lines printed by the go
+// command, relative to the module, indented in the output of the tests
+var goPosition = regexp.MustCompile(`(?m)^([ \t]*)(\./)?([^\s:/][^\s:]*\.go:\d+)`)
+
+// relativeToRoot rewrites the file:line references of the go command output, relative to the
+// module, to be relative to the root of the repository
+func relativeToRoot(output []byte, module string) []byte {
+	if module == "." {
+		return output
+	}
+	return goPosition.ReplaceAllFunc(output, func(m []byte) []byte {
+		sub := goPosition.FindSubmatch(m)
+		return []byte(string(sub[1]) + path.Join(module, string(sub[3])))
+	})
+}
+
+// goTestPosition matches the file:line references go test prints for failures, indented
+// and relative to the directory of the package
+var goTestPosition = regexp.MustCompile(`^([ \t]+)([^\s:/]+\.go:\d+)`)
+
+// goTestResult matches the line go test prints after the output of a package, e.g.
+// FAIL<tab>example.com/demo/calc<tab>0.01s
+var goTestResult = regexp.MustCompile(`^(?:ok|FAIL|\?)\s*\t(\S+)`)
+
+// packageRelativeToModule rewrites the file:line references of the go test output, relative
+// to the package, to be relative to the module. go test prints the output of each package
+// before its result line, which tells which package the references belong to
+func packageRelativeToModule(output []byte, modulePath string) []byte {
+	if modulePath == "" {
+		return output
+	}
+	lines := strings.SplitAfter(string(output), "\n")
+	pending := []int{}
+	for i, line := range lines {
+		if goTestPosition.MatchString(line) {
+			pending = append(pending, i)
+			continue
+		}
+		m := goTestResult.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		dir, ok := "", m[1] == modulePath
+		if !ok {
+			dir, ok = strings.CutPrefix(m[1], modulePath+"/")
+		}
+		for _, j := range pending {
+			if ok && dir != "" {
+				lines[j] = goTestPosition.ReplaceAllString(lines[j], "${1}"+dir+"/${2}")
+			}
+		}
+		pending = pending[:0]
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// goModulePath returns the module path declared in the go.mod of a directory, empty if unknown
+func goModulePath(dir string) string {
+	content, err := os.ReadFile(filepath.Join(filepath.FromSlash(dir), "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// runGo runs the go command in a module, writing its output with the file references of the repository
+func runGo(ctx *builtinContext, module *goModule, args ...string) error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Dir = filepath.FromSlash(module.dir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	ctx.stdout.Write(relativeToRoot(packageRelativeToModule(stdout.Bytes(), goModulePath(module.dir)), module.dir))
+	ctx.stderr.Write(relativeToRoot(stderr.Bytes(), module.dir))
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fmt.Errorf("failed to run go: %w", err)
+	}
+	return err
+}
+
+// goCommand runs a go subcommand on the packages of the changed files, module by module
+func goCommand(ctx *builtinContext, subcommand string, args ...string) error {
+	// the removed files find the packages that lost code, even when their directory is gone
+	modules, _ := goModules(append(append([]string{}, ctx.files...), ctx.removed...))
+	if len(modules) == 0 {
+		fmt.Fprintln(ctx.stdout, "no Go module contains the changed files")
+		return nil
+	}
+
+	affected, err := boolOption(ctx, "affected")
+	if err != nil {
+		return err
+	}
+
+	args = append(args, strings.Fields(ctx.options["args"])...)
+	failed := []string{}
+	for _, module := range modules {
+		packages := module.packages
+		if affected {
+			if packages, err = affectedPackages(ctx, module); err != nil {
+				return err
+			}
+		} else {
+			packages = existingPackages(module)
+		}
+		if len(packages) == 0 {
+			if module.dir != "." {
+				fmt.Fprintf(ctx.stdout, "module %s: ", module.dir)
+			}
+			fmt.Fprintln(ctx.stdout, "no package left to check")
+			continue
+		}
+		if module.dir != "." {
+			fmt.Fprintf(ctx.stdout, "module %s: go %s %s\n", module.dir, subcommand, strings.Join(packages, " "))
+		}
+		if err := runGo(ctx, module, append(append([]string{subcommand}, args...), packages...)...); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return err
+			}
+			failed = append(failed, module.dir)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("go %s failed in %s", subcommand, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// existingPackages returns the packages of a module whose directory still exists
+func existingPackages(module *goModule) []string {
+	packages := []string{}
+	for _, pkg := range module.packages {
+		if pkg == "./..." {
+			packages = append(packages, pkg)
+		} else if info, err := os.Stat(filepath.Join(filepath.FromSlash(module.dir), filepath.FromSlash(pkg))); err == nil && info.IsDir() {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// boolOption returns the value of a boolean option of the step, false when it is not set
+func boolOption(ctx *builtinContext, name string) (bool, error) {
+	value, ok := ctx.options[name]
+	if !ok {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value '%s' of option %s", value, name)
+	}
+	return b, nil
+}
+
+// builtinGoVet runs go vet on the packages of the changed files
+func builtinGoVet(ctx *builtinContext) error {
+	return goCommand(ctx, "vet")
+}
+
+// builtinGoTest runs go test on the packages of the changed files
+func builtinGoTest(ctx *builtinContext) error {
+	return goCommand(ctx, "test")
+}
+
+// builtinGoBuild builds the packages of the changed files, discarding the binaries
+func builtinGoBuild(ctx *builtinContext) error {
+	out, err := os.MkdirTemp("", "husky-go-build-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(out)
+
+	// -o to a directory accepts any number of packages, main or not
+	return goCommand(ctx, "build", "-o", out+string(os.PathSeparator))
+}
+
+// builtinGoFmt reports the changed Go files gofmt would change, or formats them with fix: true
+func builtinGoFmt(ctx *builtinContext) error {
+	fix, err := boolOption(ctx, "fix")
+	if err != nil {
+		return err
+	}
+
+	files := []string{}
+	for _, file := range ctx.files {
+		if strings.HasSuffix(file, ".go") {
+			files = append(files, filepath.FromSlash(file))
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	if fix {
+		var stderr bytes.Buffer
+		cmd := exec.Command("gofmt", append([]string{"-w"}, files...)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			ctx.stderr.Write(stderr.Bytes())
+			return errors.New("gofmt failed")
+		}
+		return nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("gofmt", append([]string{"-l"}, files...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// syntax errors already come with their position
+		ctx.stderr.Write(stderr.Bytes())
+		return errors.New("gofmt failed")
+	}
+
+	unformatted := strings.Fields(stdout.String())
+	for _, file := range unformatted {
+		diff, _ := exec.Command("gofmt", "-d", file).Output()
+		fmt.Fprintf(ctx.stderr, "%s:%d: not formatted, run gofmt -w %s\n", filepath.ToSlash(file), firstChangedLine(string(diff)), file)
+	}
+
+	switch len(unformatted) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("1 file is not formatted")
+	default:
+		return fmt.Errorf("%d files are not formatted", len(unformatted))
+	}
+}
+
+// firstChangedLine returns the line of the first change of a unified diff, 1 if unknown
+func firstChangedLine(diff string) int {
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "@@"):
+			if line > 0 {
+				return line
+			}
+			if _, err := fmt.Sscanf(text, "@@ -%d", &line); err != nil {
+				return 1
+			}
+		case line == 0:
+			continue
+		case strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+"):
+			return max(line, 1)
+		default:
+			line++
+		}
+	}
+	return 1
+}
+
+// builtinGoModTidyCheck reports the modules whose go.mod or go.sum go mod tidy would change.
+// Tidy runs on copies of the files with -modfile, the files of the module are never written
+func builtinGoModTidyCheck(ctx *builtinContext) error {
+	modules, _ := goModules(ctx.files)
+	untidy := []string{}
+
+	for _, module := range modules {
+		changed, err := tidyChanges(ctx, module)
+		if err != nil {
+			return err
+		}
+		if changed {
+			untidy = append(untidy, module.dir)
+		}
+	}
+
+	if len(untidy) > 0 {
+		return fmt.Errorf("go mod tidy changes %s", strings.Join(untidy, ", "))
+	}
+	return nil
+}
+
+// tidyChanges runs go mod tidy on temporary copies of the go.mod and go.sum of a module and
+// reports the first line it changes in each of them
+func tidyChanges(ctx *builtinContext, module *goModule) (bool, error) {
+	tmp, err := os.MkdirTemp("", "husky-go-mod-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// -modfile reads and writes the go.sum next to the go.mod it is given
+	files := []string{"go.mod", "go.sum"}
+	before := map[string][]byte{}
+	for _, name := range files {
+		content, err := os.ReadFile(filepath.Join(filepath.FromSlash(module.dir), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		before[name] = content
+		if err := os.WriteFile(filepath.Join(tmp, name), content, 0644); err != nil {
+			return false, err
+		}
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command("go", "mod", "tidy", "-modfile="+filepath.Join(tmp, "go.mod"))
+	cmd.Dir = filepath.FromSlash(module.dir)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		ctx.stderr.Write(relativeToRoot(output.Bytes(), module.dir))
+		return true, nil
+	}
+
+	for _, name := range files {
+		after, _ := os.ReadFile(filepath.Join(tmp, name))
+		if !bytes.Equal(before[name], after) {
+			file := path.Join(module.dir, name)
+			fmt.Fprintf(ctx.stderr, "%s:%d: not tidy, run go mod tidy in %s\n", file, firstDifferentLine(before[name], after), module.dir)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// firstDifferentLine returns the first line that differs between two versions of a file
+func firstDifferentLine(a, b []byte) int {
+	x, y := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return i + 1
+		}
+	}
+	return min(len(x), len(y)) + 1
+}
@@ -0,0 +1,177 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes files relative to the current directory, creating their directories
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGoModules(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	writeFiles(t, map[string]string{
+		"go.mod":          "module example.com/root\n",
+		"tools/go.mod":    "module example.com/tools\n",
+		"scripts/run.go":  "",
+		"services/go.mod": "module example.com/services\n",
+	})
+
+	modules, outside := goModules([]string{
+		"main.go", "internal/a/a.go", "internal/a/a_test.go", "testdata/x.go", "vendor/v/v.go",
+		"tools/gen/gen.go", "tools/go.sum",
+		"services/api/api.go",
+	})
+	assert.Empty(t, outside)
+	if assert.Len(t, modules, 3) {
+		assert.Equal(t, &goModule{dir: ".", packages: []string{".", "./internal/a"}}, modules[0])
+		assert.Equal(t, &goModule{dir: "services", packages: []string{"./api"}}, modules[1])
+		assert.Equal(t, &goModule{dir: "tools", packages: []string{"./..."}}, modules[2])
+	}
+
+	os.Remove("go.mod")
+	modules, outside = goModules([]string{"main.go", "services/api/api.go"})
+	assert.Equal(t, []string{"main.go"}, outside)
+	assert.Len(t, modules, 1)
+}
+
+func TestGoOutputHelpers(t *testing.T) {
+	out := relativeToRoot([]byte("# example.com/tools\n./gen/gen.go:3:2: undefined: x\nvet: gen/b.go:1:1: oops\n"), "tools")
+	assert.Equal(t, "# example.com/tools\ntools/gen/gen.go:3:2: undefined: x\nvet: gen/b.go:1:1: oops\n", string(out))
+	assert.Equal(t, "./a.go:1:1: x\n", string(relativeToRoot([]byte("./a.go:1:1: x\n"), ".")))
+	// indented positions keep their indentation, absolute ones are left alone
+	out = relativeToRoot([]byte("    gen/gen_test.go:6: boom\n\t/usr/lib/go/src/testing/testing.go:1690 +0x10\n"), "tools")
+	assert.Equal(t, "    tools/gen/gen_test.go:6: boom\n\t/usr/lib/go/src/testing/testing.go:1690 +0x10\n", string(out))
+
+	test := "--- FAIL: TestA (0.00s)\n    a_test.go:6: boom\nFAIL\nFAIL\texample.com/tools/gen\t0.004s\n--- FAIL: TestB (0.00s)\n    b_test.go:9: bang\nFAIL\nFAIL\texample.com/tools\t0.003s\nFAIL\n"
+	assert.Equal(t, "--- FAIL: TestA (0.00s)\n    gen/a_test.go:6: boom\nFAIL\nFAIL\texample.com/tools/gen\t0.004s\n--- FAIL: TestB (0.00s)\n    b_test.go:9: bang\nFAIL\nFAIL\texample.com/tools\t0.003s\nFAIL\n", string(packageRelativeToModule([]byte(test), "example.com/tools")))
+
+	diff := "diff a.go gofmt/a.go\n--- a.go\n+++ gofmt/a.go\n@@ -3,7 +3,7 @@\n import \"fmt\"\n \n func main() {\n-\tfmt.Println( 1)\n+\tfmt.Println(1)\n }\n"
+	assert.Equal(t, 6, firstChangedLine(diff))
+	assert.Equal(t, 1, firstChangedLine(""))
+
+	assert.Equal(t, 2, firstDifferentLine([]byte("module x\n\ngo 1.22\n"), []byte("module x\ngo 1.22\n")))
+	assert.Equal(t, 1, firstDifferentLine(nil, []byte("x v1.0.0 h1:abc\n")))
+
+	assert.True(t, ignoredByGo("internal/testdata"))
+	assert.True(t, ignoredByGo("_tools"))
+	assert.False(t, ignoredByGo("."))
+}
+
+func TestGoBuiltins(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	initGitRepo(t)
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	writeFiles(t, map[string]string{
+		"go.mod":         "module example.com/demo\n\ngo 1.21\n",
+		"main.go":        "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"demo\")\n}\n",
+		"calc/calc.go":   "package calc\n\n// Add adds\nfunc Add(a, b int) int { return a + b }\n",
+		"other/other.go": "package other\n",
+		".husky/husky.yaml": `hooks:
+  pre-commit:
+    commands:
+      - builtin: go:fmt
+      - builtin: go:vet
+      - builtin: go:build
+      - builtin: go:mod-tidy-check
+      - builtin: go:test
+        with:
+          args: -count=1
+`,
+	})
+	gitCmd(t, "add", "go.mod", "main.go", "calc")
+
+	run := func() (*RunResult, string) {
+		t.Helper()
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		result, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: stderr, Quiet: true})
+		assert.NoError(t, err)
+		return result, stdout.String() + stderr.String()
+	}
+
+	result, out := run()
+	assert.False(t, result.Failed(), out)
+	assert.Len(t, result.Commands, 5)
+	assert.NoFileExists(t, "demo")
+
+	// unformatted code is reported with its line, the other steps are not run
+	writeFiles(t, map[string]string{"calc/calc.go": "package calc\n\n// Add adds\nfunc Add(a, b int) int {\n\treturn a +  b\n}\n"})
+	gitCmd(t, "add", "calc")
+	result, out = run()
+	assert.True(t, result.Failed())
+	assert.Contains(t, out, "[go:fmt] calc/calc.go:5: not formatted, run gofmt -w calc/calc.go")
+
+	// go vet problems keep the position go reports
+	writeFiles(t, map[string]string{"calc/calc.go": "package calc\n\nimport \"fmt\"\n\n// Add adds\nfunc Add(a, b int) int {\n\tfmt.Printf(\"%d\\n\", \"x\")\n\treturn a + b\n}\n"})
+	gitCmd(t, "add", "calc")
+	result, out = run()
+	assert.True(t, result.Failed())
+	assert.Contains(t, out, "calc/calc.go:7:")
+	assert.Contains(t, out, "go vet failed in .")
+
+	// an untidy go.mod is reported and left untouched
+	writeFiles(t, map[string]string{
+		"calc/calc.go": "package calc\n\n// Add adds\nfunc Add(a, b int) int { return a + b }\n",
+		"go.mod":       "module example.com/demo\n\ngo 1.21\n\n\n",
+	})
+	gitCmd(t, "add", "go.mod", "calc")
+	result, out = run()
+	assert.True(t, result.Failed())
+	assert.Contains(t, out, "go.mod:5: not tidy, run go mod tidy in .")
+	content, _ := os.ReadFile("go.mod")
+	assert.Equal(t, "module example.com/demo\n\ngo 1.21\n\n\n", string(content))
+
+	// fix: true formats the files instead of failing
+	hook := &HookConfig{Commands: []*HookCommand{{Builtin: "go:fmt", With: map[string]string{"fix": "true"}}}}
+	assert.NoError(t, hook.Validate())
+	writeFiles(t, map[string]string{"calc/calc.go": "package calc\nfunc Add(a, b int) int { return a +  b }\n"})
+	err := builtinGoFmt(&builtinContext{files: []string{"calc/calc.go"}, options: map[string]string{"fix": "true"}, stdout: new(bytes.Buffer), stderr: new(bytes.Buffer)})
+	assert.NoError(t, err)
+	content, _ = os.ReadFile("calc/calc.go")
+	assert.Contains(t, string(content), "a + b")
+
+	hook.Commands[0].With = map[string]string{"args": "-race"}
+	assert.ErrorContains(t, hook.Validate(), "unknown option 'args' of builtin 'go:fmt'")
+	hook.Commands[0] = &HookCommand{Run: "exit 0", With: map[string]string{"fix": "true"}}
+	assert.ErrorContains(t, hook.Validate(), "declares options but no builtin")
+}
+
+func TestGoTestFailurePositions(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not available")
+	}
+	initGitRepo(t)
+
+	writeFiles(t, map[string]string{
+		"tools/go.mod":          "module example.com/tools\n\ngo 1.21\n",
+		"tools/gen/gen.go":      "package gen\n",
+		"tools/gen/gen_test.go": "package gen\n\nimport \"testing\"\n\nfunc TestGen(t *testing.T) {\n\tt.Error(\"boom\")\n}\n",
+		"tools/root_test.go":    "package tools\n\nimport \"testing\"\n\nfunc TestRoot(t *testing.T) {\n\tt.Error(\"bang\")\n}\n",
+	})
+
+	stdout := new(bytes.Buffer)
+	ctx := &builtinContext{files: []string{"tools/gen/gen_test.go", "tools/root_test.go"}, options: map[string]string{"args": "-count=1"}, stdout: stdout, stderr: stdout}
+	assert.EqualError(t, builtinGoTest(ctx), "go test failed in tools")
+	assert.Contains(t, stdout.String(), "    tools/gen/gen_test.go:6: boom\n")
+	assert.Contains(t, stdout.String(), "    tools/root_test.go:6: bang\n")
+}
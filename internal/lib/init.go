@@ -13,6 +13,7 @@ import (
 type InitOptions struct {
 	Config    *HuskyConfig             // Husky configuration
 	Templates map[string]*HookTemplate // Hook templates
+	Template  string                   // Template applied to the config, by name
 	Force     bool                     // Force initialization
 	Quiet     bool                     // Quiet mode
 }
@@ -24,12 +25,42 @@ func Init(opts InitOptions) error {
 		return fmt.Errorf("environment validation failed: %w", err)
 	}
 
+	// Resolve the template before touching the repository
+	var template *HookTemplate
+	if opts.Template != "" {
+		tpl, err := lookupTemplate(opts.Templates, opts.Template)
+		if err != nil {
+			return err
+		}
+		template = tpl
+	}
+
 	// Create husky directory structure
 	huskyDir, err := createHuskyStructure(opts.Config)
 	if err != nil {
 		return fmt.Errorf("failed to create husky structure: %w", err)
 	}
 
+	// Write the config file declaring the default hooks
+	config, err := writeDefaultConfig(opts.Config)
+	if err != nil {
+		cleanup(huskyDir)
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	opts.Config = config
+
+	// Declare the hooks of the template
+	if template != nil {
+		if err := applyTemplate(config, template, "", opts.Force); err != nil {
+			cleanup(huskyDir)
+			return fmt.Errorf("failed to apply template: %w", err)
+		}
+		if err := SaveConfig(config); err != nil {
+			cleanup(huskyDir)
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+
 	// Install default hooks
 	if err := installDefaultHooks(huskyDir, opts); err != nil {
 		cleanup(huskyDir)
@@ -60,28 +91,45 @@ func validateEnvironment(checkExisting bool) error {
 func createHuskyStructure(config *HuskyConfig) (string, error) {
 	huskyDir := tools.GetHuskyHooksDir(true)
 
-	if err := os.MkdirAll(huskyDir, config.DefaultPermissions); err != nil {
+	if err := os.MkdirAll(huskyDir, os.FileMode(config.DefaultPermissions)); err != nil {
 		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
 	return huskyDir, nil
 }
 
-// installDefaultHooks installs the default hooks
-func installDefaultHooks(huskyDir string, opts InitOptions) error {
-	for hookName, template := range opts.Config.DefaultHooks {
-		if err := createHook(huskyDir, hookName, template, opts.Config); err != nil {
-			return fmt.Errorf("failed to create %s hook: %w", hookName, err)
-		}
+// writeDefaultConfig writes the config file declaring the default hooks,
+// keeping the existing one if the project already has a config file
+func writeDefaultConfig(config *HuskyConfig) (*HuskyConfig, error) {
+	existing, err := LoadConfig()
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if existing.Loaded() {
+		return existing, nil
+	}
+
+	for _, hookName := range DefaultHooks {
+		config.Hook(hookName)
+	}
+
+	if err := SaveConfig(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// installDefaultHooks installs the hooks declared in the config
+func installDefaultHooks(huskyDir string, opts InitOptions) error {
+	return writeHookScripts(huskyDir, opts.Config)
 }
 
 // createHook creates a hook
 func createHook(dir, name, content string, config *HuskyConfig) error {
 	hookPath := path.Join(dir, name)
 
-	file, err := os.OpenFile(hookPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, config.DefaultPermissions)
+	file, err := os.OpenFile(hookPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(config.DefaultPermissions))
 	if err != nil {
 		return err
 	}
@@ -96,7 +144,8 @@ func createHook(dir, name, content string, config *HuskyConfig) error {
 		return err
 	}
 
-	return nil
+	// OpenFile only applies the permissions when creating the file
+	return os.Chmod(hookPath, os.FileMode(config.DefaultPermissions))
 }
 
 // cleanup cleans up the husky directory
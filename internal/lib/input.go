@@ -0,0 +1,285 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// PushRef is a ref update sent by pre-push on its input
+type PushRef struct {
+	LocalRef  string // ref being pushed, "(delete)" when the remote ref is deleted
+	LocalOID  string
+	RemoteRef string
+	RemoteOID string // zero when the remote ref does not exist yet
+}
+
+// IsDelete reports whether the push deletes the remote ref
+func (r PushRef) IsDelete() bool {
+	return isZeroOID(r.LocalOID)
+}
+
+// IsNew reports whether the push creates the remote ref
+func (r PushRef) IsNew() bool {
+	return isZeroOID(r.RemoteOID)
+}
+
+// RefUpdate is a ref update received by the server side hooks and reference-transaction
+type RefUpdate struct {
+	OldOID string
+	NewOID string
+	Ref    string
+}
+
+// Rewrite is a commit rewritten by amend or rebase, received by post-rewrite
+type Rewrite struct {
+	OldOID string
+	NewOID string
+}
+
+// HookInput is the data git passes to a hook as arguments and input, parsed according to the hook
+type HookInput struct {
+	Hook  string
+	Args  []string
+	Stdin []byte
+
+	// pre-push
+	RemoteName string
+	RemoteURL  string
+	PushRefs   []PushRef
+
+	// applypatch-msg, commit-msg and prepare-commit-msg
+	CommitMsgFile string
+	CommitSource  string // message, template, merge, squash or commit
+	CommitSHA     string // commit being amended or reused
+
+	// post-checkout
+	CheckoutPrev     string
+	CheckoutNew      string
+	CheckoutIsBranch bool
+
+	// post-merge
+	MergeSquash bool
+
+	// pre-rebase
+	RebaseUpstream string
+	RebaseBranch   string // empty when rebasing the current branch
+
+	// post-rewrite
+	RewriteCommand string // amend or rebase
+	Rewrites       []Rewrite
+
+	// pre-receive, post-receive, update, post-update and reference-transaction
+	RefUpdates       []RefUpdate
+	TransactionState string // prepared, committed or aborted
+}
+
+// parseHookInput parses the arguments and input git passes to a hook
+func parseHookInput(hook string, args []string, stdin []byte) (*HookInput, error) {
+	input := &HookInput{Hook: hook, Args: args, Stdin: stdin}
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch hook {
+	case "pre-push":
+		input.RemoteName, input.RemoteURL = arg(0), arg(1)
+		for _, fields := range inputLines(stdin) {
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("invalid pre-push input line %q", strings.Join(fields, " "))
+			}
+			input.PushRefs = append(input.PushRefs, PushRef{fields[0], fields[1], fields[2], fields[3]})
+		}
+	case "applypatch-msg", "commit-msg":
+		input.CommitMsgFile = arg(0)
+	case "prepare-commit-msg":
+		input.CommitMsgFile, input.CommitSource, input.CommitSHA = arg(0), arg(1), arg(2)
+	case "post-checkout":
+		input.CheckoutPrev, input.CheckoutNew, input.CheckoutIsBranch = arg(0), arg(1), arg(2) == "1"
+	case "post-merge":
+		input.MergeSquash = arg(0) == "1"
+	case "pre-rebase":
+		input.RebaseUpstream, input.RebaseBranch = arg(0), arg(1)
+	case "post-rewrite":
+		input.RewriteCommand = arg(0)
+		for _, fields := range inputLines(stdin) {
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid post-rewrite input line %q", strings.Join(fields, " "))
+			}
+			input.Rewrites = append(input.Rewrites, Rewrite{fields[0], fields[1]})
+		}
+	case "update":
+		input.RefUpdates = []RefUpdate{{Ref: arg(0), OldOID: arg(1), NewOID: arg(2)}}
+	case "post-update":
+		for _, ref := range args {
+			input.RefUpdates = append(input.RefUpdates, RefUpdate{Ref: ref})
+		}
+	case "pre-receive", "post-receive", "reference-transaction":
+		if hook == "reference-transaction" {
+			input.TransactionState = arg(0)
+		}
+		for _, fields := range inputLines(stdin) {
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("invalid %s input line %q", hook, strings.Join(fields, " "))
+			}
+			input.RefUpdates = append(input.RefUpdates, RefUpdate{fields[0], fields[1], fields[2]})
+		}
+	}
+
+	return input, nil
+}
+
+// inputLines splits the hook input in lines of space separated fields, skipping blank lines
+func inputLines(stdin []byte) [][]string {
+	lines := [][]string{}
+	for _, line := range strings.Split(string(stdin), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	return lines
+}
+
+// isZeroOID reports whether the object name is made of zeros, whatever the hash algorithm
+func isZeroOID(oid string) bool {
+	return oid != "" && strings.Trim(oid, "0") == ""
+}
+
+// hookVariable is a value of the hook input exposed to the commands
+type hookVariable struct {
+	name   string   // placeholder name, the environment variable is HUSKY_<NAME>
+	values []string // space separated in the environment, quoted one by one in placeholders
+}
+
+// variables returns the values of the hook input exposed to the commands, only the ones the hook receives
+func (in *HookInput) variables() []hookVariable {
+	vars := []hookVariable{}
+	add := func(name string, values ...string) {
+		vars = append(vars, hookVariable{name, values})
+	}
+	flag := func(b bool) string {
+		if b {
+			return "true"
+		}
+		return "false"
+	}
+
+	switch in.Hook {
+	case "pre-push":
+		localRefs, remoteRefs := []string{}, []string{}
+		localOIDs, remoteOIDs := []string{}, []string{}
+		for _, ref := range in.PushRefs {
+			if !ref.IsDelete() {
+				localRefs = append(localRefs, ref.LocalRef)
+			}
+			remoteRefs = append(remoteRefs, ref.RemoteRef)
+			localOIDs = append(localOIDs, ref.LocalOID)
+			remoteOIDs = append(remoteOIDs, ref.RemoteOID)
+		}
+		add("remote_name", in.RemoteName)
+		add("remote_url", in.RemoteURL)
+		add("push_refs", remoteRefs...)
+		add("push_local_refs", localRefs...)
+		// one per ref line, in the order of push_refs
+		add("push_local_shas", localOIDs...)
+		add("push_remote_shas", remoteOIDs...)
+	case "applypatch-msg", "commit-msg":
+		add("commit_msg_file", in.CommitMsgFile)
+	case "prepare-commit-msg":
+		add("commit_msg_file", in.CommitMsgFile)
+		add("commit_source", in.CommitSource)
+		add("commit_sha", in.CommitSHA)
+	case "post-checkout":
+		add("checkout_prev", in.CheckoutPrev)
+		add("checkout_new", in.CheckoutNew)
+		add("checkout_is_branch", flag(in.CheckoutIsBranch))
+	case "post-merge":
+		add("merge_squash", flag(in.MergeSquash))
+	case "pre-rebase":
+		add("rebase_upstream", in.RebaseUpstream)
+		add("rebase_branch", in.RebaseBranch)
+	case "post-rewrite":
+		add("rewrite_command", in.RewriteCommand)
+		rewrites := []string{}
+		for _, rewrite := range in.Rewrites {
+			rewrites = append(rewrites, rewrite.OldOID+":"+rewrite.NewOID)
+		}
+		add("rewrites", rewrites...)
+	case "update", "post-update", "pre-receive", "post-receive", "reference-transaction":
+		refs := []string{}
+		for _, update := range in.RefUpdates {
+			refs = append(refs, update.Ref)
+		}
+		add("updated_refs", refs...)
+		if in.Hook == "reference-transaction" {
+			add("transaction_state", in.TransactionState)
+		}
+	}
+
+	return vars
+}
+
+// Env returns the hook input as HUSKY_* environment variables
+func (in *HookInput) Env() []string {
+	env := []string{"HUSKY_HOOK=" + in.Hook}
+	for _, v := range in.variables() {
+		env = append(env, fmt.Sprintf("HUSKY_%s=%s", strings.ToUpper(v.name), strings.Join(v.values, " ")))
+	}
+	return env
+}
+
+// expand replaces the {name} placeholders of the hook input in a command, quoted for the shell
+func (in *HookInput) expand(command string) string {
+	for _, v := range in.variables() {
+		placeholder := "{" + v.name + "}"
+		if strings.Contains(command, placeholder) {
+			command = strings.ReplaceAll(command, placeholder, shellQuote(v.values))
+		}
+	}
+	return command
+}
+
+// pushFiles returns the files changed by the pushed refs, falling back to the
+// commits not pushed to the upstream branch when the refs are unknown
+func (in *HookInput) pushFiles() ([]string, error) {
+	return in.refFiles(tools.PushFiles, tools.RangeFiles, tools.NewFiles)
+}
+
+// removedFiles returns the files deleted or renamed away by the pushed refs, like pushFiles
+func (in *HookInput) removedFiles() ([]string, error) {
+	return in.refFiles(tools.PushRemovedFiles, tools.RangeRemovedFiles, tools.NewRemovedFiles)
+}
+
+// refFiles lists files with the listing of the range of each pushed ref, the listing of the
+// new commits of the refs new to the remote, or the listing of the unpushed commits
+func (in *HookInput) refFiles(unpushed func() ([]string, error), between func(from, to string) ([]string, error), since func(to string) ([]string, error)) ([]string, error) {
+	if len(in.PushRefs) == 0 {
+		return unpushed()
+	}
+
+	files := []string{}
+	for _, ref := range in.PushRefs {
+		if ref.IsDelete() {
+			continue
+		}
+
+		var changed []string
+		var err error
+		if ref.IsNew() {
+			changed, err = since(ref.LocalOID)
+		} else if changed, err = between(ref.RemoteOID, ref.LocalOID); err != nil {
+			// the remote commit is unknown locally, e.g. after a force push from elsewhere
+			changed, err = since(ref.LocalOID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, changed...)
+	}
+
+	return tools.SplitNul(strings.Join(files, "\x00")), nil
+}
@@ -0,0 +1,164 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHookInput(t *testing.T) {
+	const (
+		oldOID = "1111111111111111111111111111111111111111"
+		newOID = "2222222222222222222222222222222222222222"
+		zero   = "0000000000000000000000000000000000000000"
+	)
+
+	tests := []struct {
+		name    string
+		hook    string
+		args    []string
+		stdin   string
+		want    func(t *testing.T, in *HookInput)
+		wantEnv []string
+		wantErr bool
+	}{
+		{
+			name:  "pre-push refs",
+			hook:  "pre-push",
+			args:  []string{"origin", "git@example.com:repo.git"},
+			stdin: "refs/heads/main " + newOID + " refs/heads/main " + oldOID + "\n(delete) " + zero + " refs/heads/old " + oldOID + "\n",
+			want: func(t *testing.T, in *HookInput) {
+				if assert.Len(t, in.PushRefs, 2) {
+					assert.False(t, in.PushRefs[0].IsDelete())
+					assert.False(t, in.PushRefs[0].IsNew())
+					assert.True(t, in.PushRefs[1].IsDelete())
+				}
+			},
+			wantEnv: []string{
+				"HUSKY_REMOTE_NAME=origin",
+				"HUSKY_REMOTE_URL=git@example.com:repo.git",
+				"HUSKY_PUSH_REFS=refs/heads/main refs/heads/old",
+				"HUSKY_PUSH_LOCAL_REFS=refs/heads/main",
+				"HUSKY_PUSH_LOCAL_SHAS=" + newOID + " " + zero,
+				"HUSKY_PUSH_REMOTE_SHAS=" + oldOID + " " + oldOID,
+			},
+		},
+		{
+			name:    "Malformed pre-push input",
+			hook:    "pre-push",
+			stdin:   "refs/heads/main\n",
+			wantErr: true,
+		},
+		{
+			name: "post-checkout",
+			hook: "post-checkout",
+			args: []string{oldOID, newOID, "1"},
+			want: func(t *testing.T, in *HookInput) {
+				assert.True(t, in.CheckoutIsBranch)
+			},
+			wantEnv: []string{"HUSKY_CHECKOUT_PREV=" + oldOID, "HUSKY_CHECKOUT_NEW=" + newOID, "HUSKY_CHECKOUT_IS_BRANCH=true"},
+		},
+		{
+			name:    "prepare-commit-msg",
+			hook:    "prepare-commit-msg",
+			args:    []string{".git/COMMIT_EDITMSG", "message"},
+			wantEnv: []string{"HUSKY_COMMIT_MSG_FILE=.git/COMMIT_EDITMSG", "HUSKY_COMMIT_SOURCE=message", "HUSKY_COMMIT_SHA="},
+		},
+		{
+			name:  "post-rewrite",
+			hook:  "post-rewrite",
+			args:  []string{"amend"},
+			stdin: oldOID + " " + newOID + "\n" + newOID + " " + oldOID + " extra\n",
+			want: func(t *testing.T, in *HookInput) {
+				assert.Equal(t, []Rewrite{{oldOID, newOID}, {newOID, oldOID}}, in.Rewrites)
+				assert.Equal(t, "echo '"+oldOID+":"+newOID+"' '"+newOID+":"+oldOID+"'", in.expand("echo {rewrites}"))
+			},
+			wantEnv: []string{"HUSKY_REWRITE_COMMAND=amend", "HUSKY_REWRITES=" + oldOID + ":" + newOID + " " + newOID + ":" + oldOID},
+		},
+		{
+			name:    "reference-transaction",
+			hook:    "reference-transaction",
+			args:    []string{"committed"},
+			stdin:   oldOID + " " + newOID + " refs/heads/main\n",
+			wantEnv: []string{"HUSKY_UPDATED_REFS=refs/heads/main", "HUSKY_TRANSACTION_STATE=committed"},
+		},
+		{
+			name:    "Hook without input",
+			hook:    "pre-commit",
+			wantEnv: []string{"HUSKY_HOOK=pre-commit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := parseHookInput(tt.hook, tt.args, []byte(tt.stdin))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.want != nil {
+				tt.want(t, in)
+			}
+			env := in.Env()
+			for _, v := range tt.wantEnv {
+				assert.Contains(t, env, v)
+			}
+		})
+	}
+}
+
+func TestHookInputExpand(t *testing.T) {
+	in, err := parseHookInput("pre-push", []string{"origin", "https://example.com/it's.git"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `echo 'origin' 'https://example.com/it'\''s.git' {unknown}`, in.expand("echo {remote_name} {remote_url} {unknown}"))
+}
+
+func TestRunPushInput(t *testing.T) {
+	initGitRepo(t)
+
+	os.WriteFile("first.go", []byte("package first\n"), 0644)
+	gitCmd(t, "add", "first.go")
+	gitCmd(t, "commit", "-q", "-m", "first")
+	first := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+
+	os.WriteFile("second.go", []byte("package second\n"), 0644)
+	gitCmd(t, "add", "second.go")
+	gitCmd(t, "commit", "-q", "-m", "second")
+	second := strings.TrimSpace(gitCmd(t, "rev-parse", "HEAD"))
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-push:
+    commands:
+      - name: files
+        run: echo {push_files}
+      - name: remote
+        run: echo $HUSKY_REMOTE_NAME {remote_url} $HUSKY_PUSH_REFS
+      - name: shas
+        run: echo {push_local_shas} $HUSKY_PUSH_REMOTE_SHAS
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	_, err := Run(RunOptions{
+		Hook:   "pre-push",
+		Args:   []string{"origin", "/srv/repo.git"},
+		Stdin:  strings.NewReader("refs/heads/main " + second + " refs/heads/main " + first + "\n"),
+		Stdout: stdout,
+		Stderr: new(bytes.Buffer),
+		Quiet:  true,
+	})
+	assert.NoError(t, err)
+
+	// only the files changed since the remote commit are pushed
+	assert.Contains(t, stdout.String(), "[files] second.go\n")
+	assert.Contains(t, stdout.String(), "[remote] origin /srv/repo.git refs/heads/main\n")
+	assert.Contains(t, stdout.String(), "[shas] "+second+" "+first+"\n")
+}
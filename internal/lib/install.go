@@ -2,17 +2,42 @@ package lib
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/vkunssec/husky/internal/tools"
 )
 
 type InstallOptions struct {
-	Quiet bool // Quiet mode
+	Quiet    bool   // Quiet mode
+	Force    bool   // Replace the hooks not managed by husky
+	Chain    bool   // Run the hooks not managed by husky before the husky ones
+	Strategy string // How hooks are installed, defaults to the config strategy
 }
 
-// Install installs husky git hooks by copying them from husky hooks directory to git hooks directory
+// Install strategies
+const (
+	StrategyLink      = "link"       // hard link the husky hooks into the git hooks directory
+	StrategySymlink   = "symlink"    // symlink the husky hooks into the git hooks directory
+	StrategyCopy      = "copy"       // copy the husky hooks into the git hooks directory
+	StrategyHooksPath = "hooks-path" // point core.hooksPath to the husky hooks directory
+)
+
+// InstallStrategies are the supported install strategies
+var InstallStrategies = []string{StrategyLink, StrategySymlink, StrategyCopy, StrategyHooksPath}
+
+// chainedSuffix is appended to the hooks not managed by husky when they are chained
+const chainedSuffix = ".pre-husky"
+
+// chainMarker identifies the wrappers chaining a previous hook with the husky one
+const chainMarker = "# husky:chained"
+
+// Install regenerates the husky hooks and installs them in the git hooks directory with the
+// strategy of the options or the config: hard links by default, symlinks, copies, or
+// core.hooksPath pointing to the husky hooks directory
 func install(opts InstallOptions) error {
 	if !opts.Quiet {
 		tools.LogInfo("Installing husky")
@@ -32,62 +57,356 @@ func install(opts InstallOptions) error {
 	gitHooksDir := tools.GetGitHooksDir(true)
 	huskyHooksDir := tools.GetHuskyHooksDir(true)
 
+	// Load the config file and regenerate the hooks it declares
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !opts.Quiet {
+		level, _ := tools.ParseLogLevel(config.LogLevel)
+		tools.SetLogLevel(level)
+	}
+
+	packages, err := loadPackages(config)
+	if err != nil {
+		return err
+	}
+	if config.Loaded() || len(packages) > 0 {
+		if err := writeHookScripts(huskyHooksDir, config, packages...); err != nil {
+			return err
+		}
+	}
+
 	// Verify if husky hooks directory exists
-	_, err := os.Stat(huskyHooksDir)
+	_, err = os.Stat(huskyHooksDir)
+	if err != nil {
+		return err
+	}
+
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = config.InstallStrategy
+	}
+	if strategy == "" {
+		strategy = StrategyLink
+	}
+	if !isValidStrategy(strategy) {
+		return fmt.Errorf("invalid install strategy '%s', use one of: %s", strategy, strings.Join(InstallStrategies, ", "))
+	}
+
+	// Create the git hooks directory if it does not exist
+	if err := os.MkdirAll(gitHooksDir, 0755); err != nil {
+		return err
+	}
+
+	// Store all hook names from husky directory
+	entries, err := os.ReadDir(huskyHooksDir)
 	if err != nil {
 		return err
 	}
+	hooks := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			hooks[entry.Name()] = true
+		}
+	}
 
-	// Remove existing git hooks directory if it exists
-	if err := os.RemoveAll(gitHooksDir); err != nil {
+	if strategy == StrategyHooksPath {
+		return installHooksPath(gitHooksDir, huskyHooksDir, hooks, opts)
+	}
+
+	// A previous hooks-path installation would make git ignore the git hooks directory
+	if err := unsetHooksPath(huskyHooksDir); err != nil {
 		return err
 	}
 
-	// Create a new git hooks directory with proper permissions
-	if err := os.Mkdir(gitHooksDir, 0700); err != nil {
+	// Detect the hooks of other tools that would be overwritten
+	conflicts, err := findConflicts(gitHooksDir, huskyHooksDir, hooks)
+	if err != nil {
 		return err
 	}
+	if len(conflicts) > 0 && !opts.Force && !opts.Chain {
+		return fmt.Errorf("hooks not managed by husky already exist in %s: %s (use --chain to run them before husky or --force to replace them)",
+			gitHooksDir, strings.Join(conflicts, ", "))
+	}
 
-	// Store all hook paths from husky directory
-	var hooks []string
-	err = filepath.Walk(huskyHooksDir, func(path string, info os.FileInfo, err error) error {
+	// Back up the hooks not managed by husky before replacing them
+	if config.BackupEnabled {
+		backup, err := backupHooks(gitHooksDir, conflicts)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to back up git hooks: %w", err)
 		}
-		hooks = append(hooks, path)
-		return nil
-	})
-	if err != nil {
+		if backup != "" && !opts.Quiet {
+			tools.LogInfo("Existing hooks backed up to %s", backup)
+		}
+	}
+
+	// Keep the hooks of other tools to run them before the husky ones
+	if opts.Chain {
+		for _, hook := range conflicts {
+			if err := os.Rename(filepath.Join(gitHooksDir, hook), filepath.Join(gitHooksDir, hook+chainedSuffix)); err != nil {
+				return err
+			}
+		}
+	}
+	chained := map[string]bool{}
+
+	// Remove the hooks installed by husky that are no longer defined
+	if err := removeStaleHooks(gitHooksDir, huskyHooksDir, hooks); err != nil {
 		return err
 	}
 
 	// Process each hook file
-	for _, hook := range hooks {
-		// Skip the husky hooks directory itself
-		if hook == huskyHooksDir {
-			continue
-		}
+	names := make([]string, 0, len(hooks))
+	for hook := range hooks {
+		names = append(names, hook)
+	}
+	sort.Strings(names)
+
+	fallbacks := []string{}
+	for _, name := range names {
+		hook := filepath.Join(huskyHooksDir, name)
+		target := filepath.Join(gitHooksDir, name)
 
 		if !opts.Quiet {
 			tools.LogInfo(hook)
 		}
 
-		// Create a hard link from husky hook to git hooks directory
-		err = os.Link(hook, filepath.Join(gitHooksDir, filepath.Base(hook)))
-		if err != nil {
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 
-		// Set proper execution permissions for the hook
-		err = os.Chmod(filepath.Join(gitHooksDir, filepath.Base(hook)), 0700)
+		if _, err := os.Stat(target + chainedSuffix); err == nil {
+			// Run the previous hook before the husky one
+			chained[name] = true
+			if err := os.WriteFile(target, []byte(renderChainScript(name, hook)), 0700); err != nil {
+				return err
+			}
+			continue
+		}
+
+		used, err := installHookFile(strategy, hook, target)
 		if err != nil {
 			return err
 		}
+		if used != strategy {
+			fallbacks = append(fallbacks, name)
+		}
+	}
+
+	if !opts.Quiet {
+		for name := range chained {
+			tools.LogInfo("Hook '%s' chained with %s", name, filepath.Join(gitHooksDir, name+chainedSuffix))
+		}
+		tools.LogInfo("Hooks installed using the '%s' strategy", strategy)
+		if len(fallbacks) > 0 {
+			tools.LogInfo("Could not %s %s, copied instead", strategy, strings.Join(fallbacks, ", "))
+		}
+	}
+
+	return nil
+}
+
+// isValidStrategy checks if the install strategy is supported
+func isValidStrategy(strategy string) bool {
+	for _, s := range InstallStrategies {
+		if s == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// installHookFile installs a husky hook into the git hooks directory with the given strategy,
+// falling back to a copy when links are not supported, and returns the strategy used
+func installHookFile(strategy, hook, target string) (string, error) {
+	var err error
+	switch strategy {
+	case StrategyLink:
+		// Create a hard link from husky hook to git hooks directory
+		err = os.Link(hook, target)
+	case StrategySymlink:
+		link, rerr := filepath.Rel(filepath.Dir(target), hook)
+		if rerr != nil || filepath.IsAbs(hook) {
+			link = hook
+		}
+		err = os.Symlink(link, target)
+	default:
+		err = copyFile(hook, target)
+	}
+
+	if err != nil && strategy != StrategyCopy {
+		tools.LogDebug("failed to %s %s: %v", strategy, hook, err)
+		strategy = StrategyCopy
+		err = copyFile(hook, target)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Set proper execution permissions for the hook
+	if err := os.Chmod(target, 0700); err != nil {
+		return "", err
+	}
+
+	return strategy, nil
+}
+
+// installHooksPath points core.hooksPath to the husky hooks directory, so git runs
+// the husky hooks directly and ignores the git hooks directory
+func installHooksPath(gitHooksDir, huskyHooksDir string, hooks map[string]bool, opts InstallOptions) error {
+	if opts.Chain {
+		return fmt.Errorf("--chain is not supported by the '%s' strategy", StrategyHooksPath)
+	}
+
+	// Every hook left in the git hooks directory would stop running
+	foreign, err := foreignHooks(gitHooksDir, huskyHooksDir)
+	if err != nil {
+		return err
+	}
+	active := []string{}
+	for _, hook := range foreign {
+		if !strings.HasSuffix(hook, ".sample") {
+			active = append(active, hook)
+		}
+	}
+	// hooks chained with husky are put back in the git hooks directory, where they would not run either
+	chained, err := filepath.Glob(filepath.Join(gitHooksDir, "*"+chainedSuffix))
+	if err != nil {
+		return err
+	}
+	for _, hook := range chained {
+		active = append(active, filepath.Base(hook))
+	}
+	if len(active) > 0 && !opts.Force {
+		return fmt.Errorf("git ignores %s when core.hooksPath is set and these hooks would stop running: %s (use --force to install anyway)",
+			gitHooksDir, strings.Join(active, ", "))
+	}
+
+	// Remove the hooks installed with other strategies
+	if err := removeStaleHooks(gitHooksDir, huskyHooksDir, map[string]bool{}); err != nil {
+		return err
+	}
+
+	for hook := range hooks {
+		if !opts.Quiet {
+			tools.LogInfo(filepath.Join(huskyHooksDir, hook))
+		}
+		if err := os.Chmod(filepath.Join(huskyHooksDir, hook), 0755); err != nil {
+			return err
+		}
+	}
+
+	// a relative core.hooksPath is resolved from the root of the working tree
+	if err := tools.SetGitConfig("core.hooksPath", filepath.ToSlash(tools.RelToWorkTree(huskyHooksDir))); err != nil {
+		return err
 	}
 
 	if !opts.Quiet {
-		tools.LogInfo("Hooks installed")
+		tools.LogInfo("Hooks installed using the '%s' strategy", StrategyHooksPath)
+	}
+
+	return nil
+}
+
+// isHuskyHooksPath reports whether core.hooksPath points to the husky hooks directory
+func isHuskyHooksPath(huskyHooksDir string) bool {
+	hooksPath := tools.GetGitConfig("core.hooksPath")
+	if hooksPath == "" {
+		return false
+	}
+	if repo, err := tools.FindRepository(); err == nil && !filepath.IsAbs(hooksPath) {
+		hooksPath = filepath.Join(repo.WorkTree, hooksPath)
+	}
+	return samePath(hooksPath, huskyHooksDir)
+}
+
+// unsetHooksPath removes core.hooksPath if it points to the husky hooks directory
+func unsetHooksPath(huskyHooksDir string) error {
+	if !isHuskyHooksPath(huskyHooksDir) {
+		return nil
+	}
+	return tools.UnsetGitConfig("core.hooksPath")
+}
+
+// samePath reports whether both paths refer to the same location
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && filepath.Clean(absA) == filepath.Clean(absB)
+}
+
+// findConflicts returns the hooks to install that already exist in the git hooks directory and are not managed by husky
+func findConflicts(gitHooksDir, huskyHooksDir string, hooks map[string]bool) ([]string, error) {
+	foreign, err := foreignHooks(gitHooksDir, huskyHooksDir)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []string{}
+	for _, hook := range foreign {
+		if hooks[hook] {
+			conflicts = append(conflicts, hook)
+		}
+	}
+	return conflicts, nil
+}
+
+// removeStaleHooks removes the hooks managed by husky that are not defined anymore,
+// putting back the hooks they were chained with
+func removeStaleHooks(gitHooksDir, huskyHooksDir string, hooks map[string]bool) error {
+	entries, err := os.ReadDir(gitHooksDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || hooks[name] || strings.HasSuffix(name, chainedSuffix) {
+			continue
+		}
+
+		target := filepath.Join(gitHooksDir, name)
+		if !isManagedHook(target, filepath.Join(huskyHooksDir, name)) {
+			continue
+		}
+		if err := unlinkHook(target); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// unlinkHook removes a hook managed by husky, putting back the hook it was chained with
+func unlinkHook(target string) error {
+	if err := os.Remove(target); err != nil {
+		return err
+	}
+	if _, err := os.Stat(target + chainedSuffix); err == nil {
+		return os.Rename(target+chainedSuffix, target)
+	}
 	return nil
 }
+
+// renderChainScript renders a hook running the previous hook and then the husky one,
+// both receiving the same arguments and input. Git runs hooks from the root of the
+// working tree, so the husky hook is referenced relative to it
+func renderChainScript(name, huskyHook string) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(fmt.Sprintf("# Husky %s hook, chained with the previous hook\n", name))
+	sb.WriteString(chainMarker + "\n\n")
+	sb.WriteString("input=\"\"\n")
+	sb.WriteString("if [ ! -t 0 ]; then\n")
+	sb.WriteString("    input=\"$(mktemp)\"\n")
+	sb.WriteString("    trap 'rm -f \"$input\"' EXIT\n")
+	sb.WriteString("    cat > \"$input\"\n")
+	sb.WriteString("fi\n\n")
+	sb.WriteString("run() {\n")
+	sb.WriteString("    if [ -n \"$input\" ]; then \"$@\" < \"$input\"; else \"$@\"; fi\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString(fmt.Sprintf("run \"$(dirname \"$0\")/%s%s\" \"$@\" || exit $?\n", name, chainedSuffix))
+	sb.WriteString(fmt.Sprintf("run %s \"$@\"\n", shellQuote([]string{filepath.ToSlash(tools.RelToWorkTree(huskyHook))})))
+	return sb.String()
+}
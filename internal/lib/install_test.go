@@ -2,6 +2,7 @@ package lib
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -194,3 +195,181 @@ func TestInstall(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallConflicts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+
+	setup := func(t *testing.T) {
+		tmpDir := t.TempDir()
+		originalWd, _ := os.Getwd()
+		os.Chdir(tmpDir)
+		t.Cleanup(func() { os.Chdir(originalWd) })
+
+		os.MkdirAll(".git/hooks", 0755)
+		os.WriteFile(".git/hooks/pre-push", []byte("#!/bin/sh\necho \"foreign $1 $(cat)\"\n"), 0755)
+		os.WriteFile(".git/hooks/post-merge", []byte("#!/bin/sh\necho other\n"), 0755)
+		os.MkdirAll(".husky/hooks", 0755)
+		os.WriteFile(".husky/hooks/pre-push", []byte("#!/bin/sh\necho \"husky $1 $(cat)\"\n"), 0755)
+	}
+
+	t.Run("Refuse to overwrite foreign hooks", func(t *testing.T) {
+		setup(t)
+
+		err := Install(InstallOptions{Quiet: true})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "pre-push")
+
+		content, _ := os.ReadFile(".git/hooks/pre-push")
+		assert.Contains(t, string(content), "foreign")
+	})
+
+	t.Run("Chain foreign hooks", func(t *testing.T) {
+		setup(t)
+
+		assert.NoError(t, Install(InstallOptions{Quiet: true, Chain: true}))
+
+		cmd := exec.Command(".git/hooks/pre-push", "origin")
+		cmd.Stdin = strings.NewReader("refs")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		assert.Equal(t, "foreign origin refs\nhusky origin refs\n", string(out))
+
+		// unrelated hooks are left untouched and the foreign hook is backed up
+		content, _ := os.ReadFile(".git/hooks/post-merge")
+		assert.Equal(t, "#!/bin/sh\necho other\n", string(content))
+		backups, _ := listBackups()
+		assert.Len(t, backups, 1)
+
+		// reinstalling keeps the chain
+		assert.NoError(t, Install(InstallOptions{Quiet: true}))
+		content, _ = os.ReadFile(".git/hooks/pre-push")
+		assert.Contains(t, string(content), chainMarker)
+
+		// removing the husky hook puts back the chained hook
+		os.Remove(".husky/hooks/pre-push")
+		assert.NoError(t, Install(InstallOptions{Quiet: true}))
+		content, _ = os.ReadFile(".git/hooks/pre-push")
+		assert.Contains(t, string(content), "foreign")
+	})
+
+	t.Run("Refuse to switch chained hooks to hooks-path", func(t *testing.T) {
+		setup(t)
+		assert.NoError(t, Install(InstallOptions{Quiet: true, Chain: true}))
+		os.Remove(".git/hooks/post-merge")
+
+		err := Install(InstallOptions{Quiet: true, Strategy: StrategyHooksPath})
+		assert.ErrorContains(t, err, "these hooks would stop running: pre-push.pre-husky")
+
+		content, _ := os.ReadFile(".git/hooks/pre-push")
+		assert.Contains(t, string(content), chainMarker)
+		assert.FileExists(t, ".git/hooks/pre-push.pre-husky")
+	})
+}
+
+func TestInstallStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		check    func(t *testing.T, gitHook, huskyHook string)
+	}{
+		{
+			strategy: StrategyLink,
+			check: func(t *testing.T, gitHook, huskyHook string) {
+				gitInfo, _ := os.Stat(gitHook)
+				huskyInfo, _ := os.Stat(huskyHook)
+				assert.True(t, os.SameFile(gitInfo, huskyInfo))
+			},
+		},
+		{
+			strategy: StrategySymlink,
+			check: func(t *testing.T, gitHook, huskyHook string) {
+				link, err := os.Readlink(gitHook)
+				assert.NoError(t, err)
+				assert.Equal(t, filepath.Join("..", "..", huskyHook), link)
+			},
+		},
+		{
+			strategy: StrategyCopy,
+			check: func(t *testing.T, gitHook, huskyHook string) {
+				gitInfo, _ := os.Lstat(gitHook)
+				huskyInfo, _ := os.Stat(huskyHook)
+				assert.True(t, gitInfo.Mode().IsRegular())
+				assert.False(t, os.SameFile(gitInfo, huskyInfo))
+				assert.True(t, isManagedHook(gitHook, huskyHook))
+			},
+		},
+		{
+			strategy: StrategyHooksPath,
+			check: func(t *testing.T, gitHook, huskyHook string) {
+				assert.Equal(t, ".husky/hooks\n", gitCmd(t, "config", "--get", "core.hooksPath"))
+				_, err := os.Lstat(gitHook)
+				assert.True(t, os.IsNotExist(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			if runtime.GOOS == "windows" && tt.strategy == StrategySymlink {
+				t.Skip("symlinks require privileges on Windows")
+			}
+			initGitRepo(t)
+			os.RemoveAll(".git/hooks")
+
+			os.MkdirAll(".husky", 0755)
+			config := NewDefaultConfig()
+			config.InstallStrategy = tt.strategy
+			config.Hook("pre-commit").Commands = []*HookCommand{{Run: "true"}}
+			assert.NoError(t, SaveConfig(config))
+
+			// install with another strategy first to check switching between them
+			assert.NoError(t, Install(InstallOptions{Quiet: true, Strategy: StrategyHooksPath}))
+			assert.NoError(t, Install(InstallOptions{Quiet: true, Strategy: StrategyCopy}))
+			assert.NoError(t, Install(InstallOptions{Quiet: true}))
+
+			gitHook := filepath.Join(".git", "hooks", "pre-commit")
+			huskyHook := filepath.Join(".husky", "hooks", "pre-commit")
+			tt.check(t, gitHook, huskyHook)
+
+			assert.NoError(t, Uninstall(UninstallOptions{Quiet: true}))
+			_, err := os.Lstat(gitHook)
+			assert.True(t, os.IsNotExist(err))
+			assert.Empty(t, tools.GetGitConfig("core.hooksPath"))
+		})
+	}
+
+	t.Run("Invalid strategy", func(t *testing.T) {
+		initGitRepo(t)
+		os.MkdirAll(".husky/hooks", 0755)
+		err := Install(InstallOptions{Quiet: true, Strategy: "rsync"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid install strategy")
+	})
+}
+
+func TestInstallFromWorktreeSubdirectory(t *testing.T) {
+	initGitRepo(t)
+	main, _ := os.Getwd()
+
+	os.MkdirAll(".husky", 0755)
+	config := NewDefaultConfig()
+	config.Hook("pre-commit").Commands = []*HookCommand{{Run: "true"}}
+	assert.NoError(t, SaveConfig(config))
+	gitCmd(t, "add", ".")
+	gitCmd(t, "commit", "-q", "-m", "initial")
+
+	worktree := filepath.Join(t.TempDir(), "feature")
+	gitCmd(t, "worktree", "add", "-q", worktree)
+	os.MkdirAll(filepath.Join(worktree, "internal", "lib"), 0755)
+	os.Chdir(filepath.Join(worktree, "internal", "lib"))
+
+	assert.True(t, tools.GitExists())
+	assert.True(t, tools.HuskyExists())
+	assert.NoError(t, Install(InstallOptions{Quiet: true}))
+
+	// hooks are shared by all the worktrees
+	content, err := os.ReadFile(filepath.Join(main, ".git", "hooks", "pre-commit"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), shimCommand)
+}
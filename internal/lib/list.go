@@ -1,22 +1,303 @@
 package lib
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/vkunssec/husky/internal/tools"
 )
 
-func List() {
-	tools.LogUnformatted(" List of hooks implemented in the repository:\n\n")
-	hooks := tools.ValidHooksWithDescription
+const (
+	green = "\033[0;32m"
+	red   = "\033[0;31m"
+	nc    = "\033[0m"
+)
+
+// ListOptions are the options for the list command
+type ListOptions struct {
+	Installed bool      // report the state of the hooks of the repository instead of the hooks git supports
+	Steps     bool      // list the steps of the hooks declared in the config
+	Hook      string    // only list the steps of this hook
+	JSON      bool      // print JSON for tooling
+	Stdout    io.Writer // defaults to os.Stdout
+}
+
+// HookState is the state of a hook in the repository
+type HookState struct {
+	Name       string `json:"name"`
+	Defined    bool   `json:"defined"`    // declared in the config, or present in .husky/hooks
+	Installed  bool   `json:"installed"`  // present in the directory git runs hooks from
+	Managed    bool   `json:"managed"`    // the installed hook was written by husky
+	InSync     bool   `json:"in_sync"`    // the installed hook is the current husky one
+	Executable bool   `json:"executable"` // the installed hook can be run by git
+	Disabled   bool   `json:"disabled"`   // disabled in the config
+	Foreign    bool   `json:"foreign"`    // installed and not managed by husky
+	Path       string `json:"path,omitempty"`
+}
 
-	output := ""
-	for _, hook := range hooks {
-		output += fmt.Sprintf("  - %s\n", hook)
+// Problems describes what prevents the hook from running as defined
+func (s *HookState) Problems() []string {
+	problems := []string{}
+	switch {
+	case s.Foreign:
+		problems = append(problems, "foreign")
+	case s.Defined && !s.Installed:
+		problems = append(problems, "not installed")
+	case s.Installed && !s.InSync:
+		problems = append(problems, "out of sync")
 	}
-	output += "\n"
-	output += "For more information visit: https://github.com/vkunssec/husky\n"
-	output += "If you want to add a new hook, please submit a PR.\n"
+	if s.Installed && !s.Executable {
+		problems = append(problems, "not executable")
+	}
+	return problems
+}
+
+// exported functions
+var (
+	List = list
+)
 
-	tools.LogUnformatted("%s\n", output)
+// list prints the hooks git supports, or the state of the hooks of the repository
+func list(opts ListOptions) error {
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+
+	if opts.Steps || opts.Hook != "" {
+		return listSteps(opts.Stdout, opts.Hook, opts.JSON)
+	}
+
+	if !opts.Installed {
+		catalog := tools.HookCatalog()
+		if opts.JSON {
+			return writeJSON(opts.Stdout, catalog)
+		}
+
+		output := " List of hooks supported by git:\n\n"
+		output += formatHookCatalog(catalog)
+		output += "\n"
+		output += "Run 'husky list --installed' to see the hooks of the repository.\n"
+		output += "For more information visit: https://github.com/vkunssec/husky\n"
+		_, err := fmt.Fprintln(opts.Stdout, output)
+		return err
+	}
+
+	if !tools.GitExists() {
+		return errors.New("git not found")
+	}
+
+	states, err := hookStates()
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return writeJSON(opts.Stdout, states)
+	}
+
+	_, err = fmt.Fprint(opts.Stdout, formatHookStates(states))
+	return err
+}
+
+// hookStates returns the state of every hook defined by husky or installed in the repository
+func hookStates() ([]*HookState, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	packages, err := loadPackages(config)
+	if err != nil {
+		return nil, err
+	}
+
+	huskyHooksDir := tools.GetHuskyHooksDir(true)
+	gitHooksDir := tools.GetGitHooksDir(true)
+	hooksPath := isHuskyHooksPath(huskyHooksDir)
+	if hooksPath {
+		gitHooksDir = huskyHooksDir
+	}
+
+	states := map[string]*HookState{}
+	state := func(name string) *HookState {
+		if states[name] == nil {
+			states[name] = &HookState{Name: name}
+		}
+		return states[name]
+	}
+
+	for _, cfg := range append([]*HuskyConfig{config}, packageConfigs(packages)...) {
+		for name, hook := range cfg.Hooks {
+			s := state(name)
+			s.Defined = true
+			s.Disabled = s.Disabled || (hook != nil && hook.Disabled)
+		}
+	}
+	for _, name := range hookFiles(huskyHooksDir) {
+		state(name).Defined = true
+	}
+	for _, name := range hookFiles(gitHooksDir) {
+		state(name)
+	}
+
+	result := make([]*HookState, 0, len(states))
+	for name, s := range states {
+		gitHook := filepath.Join(gitHooksDir, name)
+		info, err := os.Stat(gitHook)
+		if err == nil {
+			s.Installed = true
+			s.Path = gitHook
+			s.Executable = info.Mode()&0111 != 0
+			huskyHook := filepath.Join(huskyHooksDir, name)
+			s.Managed = hooksPath || isManagedHook(gitHook, huskyHook)
+			s.InSync = hooksPath || (s.Managed && hookInSync(gitHook, huskyHook))
+			s.Foreign = !s.Managed
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// hookInSync reports whether the installed hook is what husky installs for the husky hook:
+// a hard link to it, a symlink resolving to it, an identical copy, or the chain wrapper
+// husky would write for it
+func hookInSync(gitHook, huskyHook string) bool {
+	if _, err := os.Stat(huskyHook); err != nil {
+		return false
+	}
+	if link, err := os.Readlink(gitHook); err == nil {
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(gitHook), link)
+		}
+		return samePath(link, huskyHook)
+	}
+	if sameFile(gitHook, huskyHook) {
+		return true
+	}
+
+	content, err := os.ReadFile(gitHook)
+	if err != nil {
+		return false
+	}
+	if bytes.Contains(content, []byte(chainMarker)) {
+		return string(content) == renderChainScript(filepath.Base(gitHook), huskyHook)
+	}
+	huskyContent, err := os.ReadFile(huskyHook)
+	return err == nil && bytes.Equal(content, huskyContent)
+}
+
+// packageConfigs returns the configs of the packages
+func packageConfigs(packages []*Package) []*HuskyConfig {
+	configs := make([]*HuskyConfig, len(packages))
+	for i, pkg := range packages {
+		configs[i] = pkg.Config
+	}
+	return configs
+}
+
+// hookFiles returns the names of the hooks found in a directory, ignoring samples and chained hooks
+func hookFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".sample") || strings.HasSuffix(name, chainedSuffix) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// formatHookStates renders the state of the hooks of the repository
+func formatHookStates(states []*HookState) string {
+	if len(states) == 0 {
+		return " No hooks defined or installed in the repository.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(" Hooks of the repository:\n\n")
+	for _, s := range states {
+		flags := []string{}
+		if s.Defined {
+			flags = append(flags, "defined")
+		}
+		if s.Installed {
+			flags = append(flags, "installed")
+		}
+		if s.InSync {
+			flags = append(flags, "in sync")
+		}
+		if s.Executable {
+			flags = append(flags, "executable")
+		}
+		if s.Disabled {
+			flags = append(flags, "disabled")
+		}
+
+		color := green
+		problems := s.Problems()
+		if len(problems) > 0 {
+			color = red
+			flags = append(flags, problems...)
+		}
+
+		name := "[" + s.Name + "]"
+		padding := strings.Repeat(" ", max(1, 25-len(name)))
+		sb.WriteString(fmt.Sprintf("  - %s%s%s%s%s\n", color, name, nc, padding, strings.Join(flags, ", ")))
+	}
+	return sb.String()
+}
+
+// writeJSON writes the value as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// formatHookCatalog renders the hooks grouped by category, flagging server side hooks
+// and hooks whose exit status cannot abort the operation
+func formatHookCatalog(catalog []tools.HookInfo) string {
+	var sb strings.Builder
+	category := ""
+	for _, hook := range catalog {
+		if hook.Category != category {
+			if category != "" {
+				sb.WriteString("\n")
+			}
+			category = hook.Category
+			sb.WriteString(fmt.Sprintf("  %s\n", category))
+		}
+
+		notes := []string{}
+		if hook.Side == tools.ServerSide {
+			notes = append(notes, "server")
+		}
+		if !hook.CanAbort {
+			notes = append(notes, "cannot abort")
+		}
+		if hook.Since != "" {
+			notes = append(notes, "git "+hook.Since+"+")
+		}
+
+		name := "[" + hook.Name + "]"
+		padding := strings.Repeat(" ", max(1, 25-len(name)))
+		line := fmt.Sprintf("  - %s%s%s%s%s", green, name, nc, padding, hook.Description)
+		if len(notes) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(notes, ", "))
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
 }
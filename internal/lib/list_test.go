@@ -0,0 +1,128 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+func TestFormatHookCatalog(t *testing.T) {
+	catalog := []tools.HookInfo{
+		{Name: "pre-commit", Category: "Commit", Description: "Before commit", Side: tools.ClientSide, CanAbort: true},
+		{Name: "post-commit", Category: "Commit", Description: "After commit", Side: tools.ClientSide},
+		{Name: "proc-receive", Category: "Server", Description: "Handles push", Side: tools.ServerSide, CanAbort: true, Since: "2.29"},
+	}
+
+	output := formatHookCatalog(catalog)
+	assert.Equal(t, 1, strings.Count(output, "  Commit\n"))
+	assert.Contains(t, output, "[pre-commit]"+nc+"             Before commit\n")
+	assert.Contains(t, output, "After commit (cannot abort)\n")
+	assert.Contains(t, output, "\n\n  Server\n")
+	assert.Contains(t, output, "Handles push (server, git 2.29+)\n")
+}
+
+func TestListInstalled(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - run: go test ./...
+  commit-msg:
+    disabled: true
+    commands:
+      - run: cat $1
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, Install(InstallOptions{Quiet: true}))
+
+	os.WriteFile(".git/hooks/post-merge", []byte("#!/bin/sh\necho foreign\n"), 0644)
+	os.Remove(".git/hooks/commit-msg")
+
+	stdout := new(bytes.Buffer)
+	assert.NoError(t, List(ListOptions{Installed: true, JSON: true, Stdout: stdout}))
+
+	var states []*HookState
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &states))
+
+	byName := map[string]*HookState{}
+	for _, s := range states {
+		byName[s.Name] = s
+	}
+	if assert.Len(t, byName, 3) {
+		assert.Equal(t, HookState{Name: "pre-commit", Defined: true, Installed: true, Managed: true, InSync: true, Executable: true, Path: byName["pre-commit"].Path}, *byName["pre-commit"])
+		assert.Empty(t, byName["pre-commit"].Problems())
+
+		assert.True(t, byName["commit-msg"].Disabled)
+		assert.Equal(t, []string{"not installed"}, byName["commit-msg"].Problems())
+
+		assert.True(t, byName["post-merge"].Foreign)
+		assert.False(t, byName["post-merge"].Defined)
+		assert.Equal(t, []string{"foreign", "not executable"}, byName["post-merge"].Problems())
+	}
+
+	stdout.Reset()
+	assert.NoError(t, List(ListOptions{Installed: true, Stdout: stdout}))
+	assert.Contains(t, stdout.String(), "defined, installed, in sync, executable\n")
+	assert.Contains(t, stdout.String(), "installed, foreign, not executable\n")
+}
+
+func TestListInstalledDrift(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `install_strategy: copy
+hooks:
+  pre-commit:
+    commands:
+      - run: go test ./...
+  pre-push:
+    commands:
+      - run: go vet ./...
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(".git/hooks", 0755)
+	os.WriteFile(".git/hooks/pre-push", []byte("#!/bin/sh\necho previous\n"), 0755)
+	assert.NoError(t, Install(InstallOptions{Quiet: true, Chain: true}))
+
+	states := func() map[string]*HookState {
+		t.Helper()
+		stdout := new(bytes.Buffer)
+		assert.NoError(t, List(ListOptions{Installed: true, JSON: true, Stdout: stdout}))
+		var list []*HookState
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &list))
+		byName := map[string]*HookState{}
+		for _, s := range list {
+			byName[s.Name] = s
+		}
+		return byName
+	}
+
+	byName := states()
+	assert.True(t, byName["pre-commit"].InSync)
+	assert.True(t, byName["pre-push"].InSync)
+
+	// a copied shim edited by hand, and a chain wrapper no longer matching its husky hook
+	content, _ := os.ReadFile(".git/hooks/pre-commit")
+	os.WriteFile(".git/hooks/pre-commit", append(content, []byte("echo edited\n")...), 0755)
+	wrapper, _ := os.ReadFile(".git/hooks/pre-push")
+	os.WriteFile(".git/hooks/pre-push", append(wrapper, []byte("echo edited\n")...), 0755)
+
+	byName = states()
+	for _, name := range []string{"pre-commit", "pre-push"} {
+		assert.True(t, byName[name].Managed, name)
+		assert.False(t, byName[name].InSync, name)
+		assert.False(t, byName[name].Foreign, name)
+		assert.Equal(t, []string{"out of sync"}, byName[name].Problems(), name)
+	}
+}
@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// Package is a sub-project of a monorepo declaring its own hooks in a nested .husky directory
+type Package struct {
+	Dir    string       // directory relative to the root of the repository, slash separated
+	Config *HuskyConfig // config of the package
+}
+
+// contains reports whether the root relative file belongs to the package
+func (p *Package) contains(file string) bool {
+	return p.Dir == "" || strings.HasPrefix(file, p.Dir+"/")
+}
+
+// rel returns the root relative file relative to the package directory
+func (p *Package) rel(file string) string {
+	if p.Dir == "" {
+		return file
+	}
+	return strings.TrimPrefix(file, p.Dir+"/")
+}
+
+// label prefixes the name with the package directory
+func (p *Package) label(name string) string {
+	if p.Dir == "" {
+		return name
+	}
+	return p.Dir + ":" + name
+}
+
+// loadPackages loads the packages listed in the config, or the ones found in the
+// repository unless the config turns discovery off
+func loadPackages(config *HuskyConfig) ([]*Package, error) {
+	dirs := config.Packages
+	if len(dirs) == 0 && config.DiscoverPackages {
+		// discovery is on by default, a repository git cannot list must not break the hooks
+		var err error
+		if dirs, err = discoverPackages(); err != nil {
+			tools.LogDebug("package discovery failed: %v", err)
+		}
+	}
+
+	packages := []*Package{}
+	for _, dir := range dirs {
+		dir = path.Clean(filepath.ToSlash(dir))
+		if dir == "." {
+			continue
+		}
+
+		huskyDir := filepath.Join(filepath.Dir(tools.GetHuskyDir(true)), filepath.FromSlash(dir), ".husky")
+		if _, err := findConfigFile(huskyDir); err != nil {
+			return nil, fmt.Errorf("package '%s': %w", dir, err)
+		}
+		packageConfig, err := loadConfigFrom(huskyDir)
+		if err != nil {
+			return nil, fmt.Errorf("package '%s': %w", dir, err)
+		}
+
+		packages = append(packages, &Package{Dir: dir, Config: packageConfig})
+	}
+
+	return packages, nil
+}
+
+// skippedDirs are never searched for packages
+var skippedDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// maxPackageDepth is how many directories below the root packages are looked for
+const maxPackageDepth = 4
+
+// discoverPackages returns the directories below the root of the repository with a .husky config.
+// Git lists the config files, so ignored directories and nested repositories are not searched
+func discoverPackages() ([]string, error) {
+	args := []string{"ls-files", "-z", "--full-name", "--cached", "--others", "--exclude-standard", "--"}
+	for _, name := range ConfigFileNames {
+		args = append(args, ":(top,glob)**/.husky/"+name)
+	}
+	out, err := tools.Git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	dirs := []string{}
+	for _, file := range tools.SplitNul(out) {
+		dir := path.Dir(path.Dir(file))
+		if dir == "." || seen[dir] || !discoverable(dir) {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// discoverable reports whether packages are looked for in a directory: not too deep, not
+// hidden and not a dependency directory
+func discoverable(dir string) bool {
+	segments := strings.Split(dir, "/")
+	if len(segments) > maxPackageDepth {
+		return false
+	}
+	for _, segment := range segments {
+		if skippedDirs[segment] || strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverPackages(t *testing.T) {
+	initGitRepo(t)
+
+	for _, dir := range []string{".husky", "api/.husky", "web/app/.husky", "node_modules/dep/.husky", "docs/.husky", "build/gen/.husky", ".cache/x/.husky", "a/b/c/d/e/.husky"} {
+		os.MkdirAll(dir, 0755)
+	}
+	for _, dir := range []string{"api", "web/app", "node_modules/dep", "build/gen", ".cache/x", "a/b/c/d/e"} {
+		os.WriteFile(filepath.Join(dir, ".husky", "husky.yaml"), []byte("hooks: {}\n"), 0644)
+	}
+	os.WriteFile(filepath.Join("web", "app", ".husky", "husky.toml"), []byte(""), 0644)
+	os.WriteFile(".gitignore", []byte("build/\n"), 0644)
+
+	dirs, err := discoverPackages()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api", "web/app"}, dirs)
+
+	// from a subdirectory too
+	os.Chdir("web")
+	dirs, err = discoverPackages()
+	os.Chdir("..")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api", "web/app"}, dirs)
+
+	// discovery is on by default and can be turned off
+	packages, err := loadPackages(NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.Len(t, packages, 2)
+
+	config := NewDefaultConfig()
+	config.DiscoverPackages = false
+	packages, err = loadPackages(config)
+	assert.NoError(t, err)
+	assert.Empty(t, packages)
+}
+
+func TestRunPackages(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	os.MkdirAll("api/.husky", 0755)
+	os.MkdirAll("web/.husky", 0755)
+
+	files := map[string]string{
+		".husky/husky.yaml": `hooks:
+  pre-commit:
+    commands:
+      - name: root
+        run: echo root
+`,
+		"api/.husky/husky.yaml": `hooks:
+  pre-commit:
+    commands:
+      - name: files
+        run: pwd; echo {staged_files}
+`,
+		"web/.husky/husky.yaml": `hooks:
+  pre-commit:
+    commands:
+      - name: lint
+        run: echo web
+`,
+		"api/main.go":  "package main\n",
+		"web/index.js": "\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd(t, "add", "api/main.go")
+
+	stdout := new(bytes.Buffer)
+	result, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: new(bytes.Buffer), Quiet: true})
+	assert.NoError(t, err)
+	assert.False(t, result.Failed())
+
+	if assert.Len(t, result.Commands, 3) {
+		assert.Equal(t, "root", result.Commands[0].Name)
+		assert.Equal(t, "api:files", result.Commands[1].Name)
+		assert.Equal(t, "web", result.Commands[2].Name)
+		assert.True(t, result.Commands[2].Skipped)
+		assert.Equal(t, "no staged files under web", result.Commands[2].SkipReason)
+	}
+
+	// package commands run in the package directory with package relative files
+	wd, _ := os.Getwd()
+	assert.Contains(t, stdout.String(), "[api:files] "+filepath.Join(wd, "api")+"\n")
+	assert.Contains(t, stdout.String(), "[api:files] main.go\n")
+	assert.NotContains(t, stdout.String(), "web")
+}
@@ -0,0 +1,192 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// Positions of the ticket in the commit message
+const (
+	TicketPrefix  = "prefix"  // before the header
+	TicketSuffix  = "suffix"  // after the header
+	TicketTrailer = "trailer" // in the trailers of the last paragraph, the default
+)
+
+// TicketPositions are the supported ticket positions
+var TicketPositions = []string{TicketPrefix, TicketSuffix, TicketTrailer}
+
+// defaultTicketPattern matches Jira-like ticket IDs, e.g. PROJ-123 in feature/PROJ-123-login
+const defaultTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// defaultTicketFormats are the formats of the ticket for each position
+var defaultTicketFormats = map[string]string{
+	TicketPrefix:  "[{ticket}] ",
+	TicketSuffix:  " ({ticket})",
+	TicketTrailer: "Refs: {ticket}",
+}
+
+// defaultSkipSources are the commit sources whose message is left alone: merges,
+// squashes and messages reused from a commit, e.g. with --amend
+var defaultSkipSources = []string{"merge", "squash", "commit"}
+
+// PrepareCommitMsgConfig are the settings of the prepare-commit-msg builtin
+type PrepareCommitMsgConfig struct {
+	TicketPattern string   `yaml:"ticket_pattern,omitempty" toml:"ticket_pattern,omitempty" json:"ticket_pattern,omitempty"` // regular expression of the ticket in the branch name, its first group if any
+	TicketFormat  string   `yaml:"ticket_format,omitempty" toml:"ticket_format,omitempty" json:"ticket_format,omitempty"`    // text inserted, {ticket} is replaced by the ticket
+	Position      string   `yaml:"position,omitempty" toml:"position,omitempty" json:"position,omitempty"`                   // prefix, suffix or trailer, the default
+	Template      string   `yaml:"template,omitempty" toml:"template,omitempty" json:"template,omitempty"`                   // message pre-filled when it is empty, {ticket} and {branch} are replaced
+	SkipSources   []string `yaml:"skip_sources,omitempty" toml:"skip_sources,omitempty" json:"skip_sources,omitempty"`       // commit sources left alone, merge, squash and commit by default
+}
+
+// Validate checks the ticket pattern, format and position
+func (c *PrepareCommitMsgConfig) Validate() error {
+	if c.TicketPattern != "" {
+		if _, err := regexp.Compile(c.TicketPattern); err != nil {
+			return fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+	}
+	if c.Position != "" && !contains(TicketPositions, c.Position) {
+		return fmt.Errorf("invalid position '%s', use one of: %s", c.Position, strings.Join(TicketPositions, ", "))
+	}
+	if c.TicketFormat != "" && !strings.Contains(c.TicketFormat, "{ticket}") {
+		return errors.New("the ticket format must contain {ticket}")
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the settings with the defaults of the unset ones
+func (c *PrepareCommitMsgConfig) withDefaults() PrepareCommitMsgConfig {
+	settings := PrepareCommitMsgConfig{}
+	if c != nil {
+		settings = *c
+	}
+	if settings.TicketPattern == "" {
+		settings.TicketPattern = defaultTicketPattern
+	}
+	// a trailer keeps the header a valid Conventional Commits one for the commit-msg builtin
+	if settings.Position == "" {
+		settings.Position = TicketTrailer
+	}
+	if settings.TicketFormat == "" {
+		settings.TicketFormat = defaultTicketFormats[settings.Position]
+	}
+	if settings.SkipSources == nil {
+		settings.SkipSources = defaultSkipSources
+	}
+	return settings
+}
+
+// branchTicket extracts the ticket from a branch name, the first group of the pattern if it has one
+func branchTicket(branch, pattern string) string {
+	m := regexp.MustCompile(pattern).FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1:
+		return m[1]
+	default:
+		return m[0]
+	}
+}
+
+// prepareCommitMessage pre-fills an empty message with the template and inserts the ticket,
+// keeping the comment lines git added. A message already mentioning the ticket is left alone
+func prepareCommitMessage(content, commentChar, ticket, branch string, settings PrepareCommitMsgConfig) string {
+	lines := strings.Split(content, "\n")
+	msg := parseCommitMessage(content, commentChar)
+
+	if len(msg.lines) == 0 && settings.Template != "" {
+		template := strings.NewReplacer("{ticket}", ticket, "{branch}", branch).Replace(settings.Template)
+		template = strings.TrimRight(template, "\n")
+		if !strings.HasPrefix(content, "\n") {
+			template += "\n"
+		}
+		content = template + "\n" + content
+		lines = strings.Split(content, "\n")
+		msg = parseCommitMessage(content, commentChar)
+	}
+
+	if ticket == "" || strings.Contains(strings.Join(messageText(msg), "\n"), ticket) {
+		return content
+	}
+	text := strings.ReplaceAll(settings.TicketFormat, "{ticket}", ticket)
+
+	switch settings.Position {
+	case TicketPrefix:
+		if len(msg.lines) == 0 {
+			return text + "\n" + content
+		}
+		lines[msg.header.number-1] = text + lines[msg.header.number-1]
+	case TicketSuffix:
+		if len(msg.lines) == 0 {
+			return content
+		}
+		lines[msg.header.number-1] = strings.TrimRight(lines[msg.header.number-1], " ") + text
+	case TicketTrailer:
+		if len(msg.lines) == 0 {
+			return "\n\n" + text + "\n" + content
+		}
+		// join the existing trailers, or start a new paragraph after the last line
+		last := msg.lines[len(msg.lines)-1].number
+		insert := []string{text}
+		if len(msg.trailers) == 0 {
+			insert = []string{"", text}
+		}
+		lines = append(lines[:last], append(insert, lines[last:]...)...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// messageText returns the lines of the message git keeps
+func messageText(msg *commitMessage) []string {
+	text := []string{}
+	for _, line := range msg.lines {
+		text = append(text, line.text)
+	}
+	return text
+}
+
+// currentBranch returns the short name of the checked out branch, empty when HEAD is detached
+func currentBranch() string {
+	out, err := tools.Git("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// builtinPrepareCommitMsg inserts the ticket of the branch in the message and pre-fills empty messages
+func builtinPrepareCommitMsg(ctx *builtinContext) error {
+	if ctx.hook != "prepare-commit-msg" || ctx.input.CommitMsgFile == "" {
+		return fmt.Errorf("the builtin runs in prepare-commit-msg, not in %s", ctx.hook)
+	}
+
+	settings := ctx.config.PrepareCommitMsg.withDefaults()
+	if contains(settings.SkipSources, ctx.input.CommitSource) {
+		return nil
+	}
+
+	content, err := os.ReadFile(ctx.input.CommitMsgFile)
+	if err != nil {
+		return err
+	}
+
+	branch := currentBranch()
+	ticket := branchTicket(branch, settings.TicketPattern)
+	// the template only fills the message of a plain commit, not of -m, -F or -t
+	if ctx.input.CommitSource != "" {
+		settings.Template = ""
+	}
+
+	prepared := prepareCommitMessage(string(content), commentChar(), ticket, branch, settings)
+	if prepared == string(content) {
+		return nil
+	}
+	return os.WriteFile(ctx.input.CommitMsgFile, []byte(prepared), 0644)
+}
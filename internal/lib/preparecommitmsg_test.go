@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const editorMessage = "\n# Please enter the commit message for your changes.\n"
+
+func TestBranchTicket(t *testing.T) {
+	tests := []struct {
+		branch, pattern, want string
+	}{
+		{"feature/PROJ-42-login", defaultTicketPattern, "PROJ-42"},
+		{"main", defaultTicketPattern, ""},
+		{"fix/123-crash", `^\w+/(\d+)-`, "123"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, branchTicket(tt.branch, tt.pattern), tt.branch)
+	}
+}
+
+func TestPrepareCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		ticket   string
+		settings PrepareCommitMsgConfig
+		want     string
+	}{
+		{"Trailer by default", "feat: add login\n" + editorMessage, "PROJ-42", PrepareCommitMsgConfig{}, "feat: add login\n\nRefs: PROJ-42\n" + editorMessage},
+		{"Prefix", "add login\n" + editorMessage, "PROJ-42", PrepareCommitMsgConfig{Position: TicketPrefix}, "[PROJ-42] add login\n" + editorMessage},
+		{"Prefix of an empty message", editorMessage, "PROJ-42", PrepareCommitMsgConfig{Position: TicketPrefix}, "[PROJ-42] \n" + editorMessage},
+		{"Suffix", "feat: add login\n", "PROJ-42", PrepareCommitMsgConfig{Position: TicketSuffix}, "feat: add login (PROJ-42)\n"},
+		{"Suffix of an empty message", editorMessage, "PROJ-42", PrepareCommitMsgConfig{Position: TicketSuffix}, editorMessage},
+		{"New trailer paragraph", "feat: add login\n\nBody.\n" + editorMessage, "PROJ-42", PrepareCommitMsgConfig{Position: TicketTrailer}, "feat: add login\n\nBody.\n\nRefs: PROJ-42\n" + editorMessage},
+		{"Existing trailers", "feat: add login\n\nSigned-off-by: Jane\n", "PROJ-42", PrepareCommitMsgConfig{Position: TicketTrailer, TicketFormat: "Jira: {ticket}"}, "feat: add login\n\nSigned-off-by: Jane\nJira: PROJ-42\n"},
+		{"Ticket already mentioned", "PROJ-42 add login\n", "PROJ-42", PrepareCommitMsgConfig{}, "PROJ-42 add login\n"},
+		{"Ticket only in a comment", "add login\n# PROJ-42\n", "PROJ-42", PrepareCommitMsgConfig{Position: TicketPrefix}, "[PROJ-42] add login\n# PROJ-42\n"},
+		{"No ticket", "add login\n", "", PrepareCommitMsgConfig{}, "add login\n"},
+		{"Template", editorMessage, "PROJ-42", PrepareCommitMsgConfig{Template: "feat: \n\nWhy:\n\nRefs: {ticket}\n"}, "feat: \n\nWhy:\n\nRefs: PROJ-42\n" + editorMessage},
+		{"Template with the ticket inserted", editorMessage, "PROJ-42", PrepareCommitMsgConfig{Template: "feat: ", Position: TicketPrefix}, "[PROJ-42] feat: \n" + editorMessage},
+		{"Template mentioning the branch", editorMessage, "PROJ-42", PrepareCommitMsgConfig{Template: "feat: \n\nOn {branch}"}, "feat: \n\nOn feature/PROJ-42-login\n" + editorMessage},
+		{"Template of a non-empty message", "fix: typo\n", "", PrepareCommitMsgConfig{Template: "feat: "}, "fix: typo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prepareCommitMessage(tt.content, "#", tt.ticket, "feature/PROJ-42-login", tt.settings.withDefaults())
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrepareCommitMsgConfigValidate(t *testing.T) {
+	assert.NoError(t, (&PrepareCommitMsgConfig{Position: TicketTrailer, TicketFormat: "Refs: {ticket}"}).Validate())
+	assert.Error(t, (&PrepareCommitMsgConfig{Position: "header"}).Validate())
+	assert.Error(t, (&PrepareCommitMsgConfig{TicketPattern: "("}).Validate())
+	assert.Error(t, (&PrepareCommitMsgConfig{TicketFormat: "Refs"}).Validate())
+}
+
+func TestBuiltinPrepareCommitMsg(t *testing.T) {
+	initGitRepo(t)
+	gitCmd(t, "checkout", "-q", "-b", "feature/PROJ-7-search")
+
+	os.MkdirAll(".husky", 0755)
+	config := `prepare_commit_msg:
+  template: "feat: "
+hooks:
+  prepare-commit-msg:
+    commands:
+      - builtin: prepare-commit-msg
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+
+	prepare := func(content string, args ...string) string {
+		t.Helper()
+		os.WriteFile(".git/COMMIT_EDITMSG", []byte(content), 0644)
+		result, err := Run(RunOptions{Hook: "prepare-commit-msg", Args: append([]string{".git/COMMIT_EDITMSG"}, args...), Stdout: new(bytes.Buffer), Stderr: new(bytes.Buffer), Quiet: true})
+		assert.NoError(t, err)
+		assert.False(t, result.Failed())
+		got, _ := os.ReadFile(".git/COMMIT_EDITMSG")
+		return string(got)
+	}
+
+	assert.Equal(t, "feat: \n\nRefs: PROJ-7\n"+editorMessage, prepare(editorMessage))
+	assert.Equal(t, "add search\n\nRefs: PROJ-7\n", prepare("add search\n", "message"))
+	assert.Equal(t, "Merge branch 'main'\n", prepare("Merge branch 'main'\n", "merge"))
+	assert.Equal(t, "add search\n", prepare("add search\n", "commit", "HEAD"))
+
+	// a detached HEAD has no branch, hence no ticket
+	gitCmd(t, "commit", "-q", "--allow-empty", "-m", "init")
+	gitCmd(t, "checkout", "-q", "--detach")
+	assert.Equal(t, "add search\n", prepare("add search\n", "message"))
+}
+
+func TestPrepareThenCheckCommitMsg(t *testing.T) {
+	initGitRepo(t)
+	gitCmd(t, "checkout", "-q", "-b", "feature/PROJ-7-search")
+
+	// both builtins with their default settings, the message they agree on must pass
+	os.MkdirAll(".husky", 0755)
+	config := `commit_msg:
+  ticket: '[A-Z]+-[0-9]+'
+hooks:
+  prepare-commit-msg:
+    commands:
+      - builtin: prepare-commit-msg
+  commit-msg:
+    commands:
+      - builtin: commit-msg
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+
+	run := func(hook string, args ...string) *RunResult {
+		t.Helper()
+		result, err := Run(RunOptions{Hook: hook, Args: append([]string{".git/COMMIT_EDITMSG"}, args...), Stdout: new(bytes.Buffer), Stderr: new(bytes.Buffer), Quiet: true})
+		assert.NoError(t, err)
+		return result
+	}
+
+	os.WriteFile(".git/COMMIT_EDITMSG", []byte("feat(search): add fuzzy matching\n"), 0644)
+	assert.False(t, run("prepare-commit-msg", "message").Failed())
+	content, _ := os.ReadFile(".git/COMMIT_EDITMSG")
+	assert.Equal(t, "feat(search): add fuzzy matching\n\nRefs: PROJ-7\n", string(content))
+	assert.False(t, run("commit-msg").Failed())
+}
@@ -0,0 +1,135 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// exported functions
+var (
+	Remove  = remove
+	Enable  = enable
+	Disable = disable
+)
+
+// checkHookCommand validates the hook and the repository before changing a hook
+func checkHookCommand(hook string) error {
+	if !tools.IsValidHook(hook) {
+		return errors.New("invalid hook")
+	}
+	if !tools.GitExists() {
+		return errors.New("git not initialized")
+	}
+	if !tools.HuskyExists() {
+		return errors.New(".husky not initialized")
+	}
+	return nil
+}
+
+// RemoveOptions are the options for the remove command
+type RemoveOptions struct {
+	Step string // only remove this step, by name or position; the hook is removed with its last step
+}
+
+// remove deletes a hook from the config and the husky hooks directory, along with the
+// hook installed in the git hooks directory, putting back the hook it was chained with
+func remove(hook string, opts RemoveOptions) error {
+	if err := checkHookCommand(hook); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if opts.Step != "" {
+		declaration, ok := config.Hooks[hook]
+		if !ok || declaration == nil {
+			return fmt.Errorf("hook '%s' is not defined in %s", hook, config.Path())
+		}
+		if err := removeStep(declaration, opts.Step); err != nil {
+			return err
+		}
+		if len(declaration.Commands) > 0 {
+			if err := SaveConfig(config); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			return nil
+		}
+	}
+
+	huskyHook := filepath.Join(tools.GetHuskyHooksDir(true), hook)
+	_, declared := config.Hooks[hook]
+	_, statErr := os.Stat(huskyHook)
+	if !declared && statErr != nil {
+		return fmt.Errorf("hook '%s' is not defined", hook)
+	}
+
+	if declared {
+		delete(config.Hooks, hook)
+		if err := SaveConfig(config); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	gitHook := filepath.Join(tools.GetGitHooksDir(true), hook)
+	if isManagedHook(gitHook, huskyHook) && !isHuskyHooksPath(tools.GetHuskyHooksDir(true)) {
+		if err := unlinkHook(gitHook); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(huskyHook); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// enable turns a disabled hook back on
+func enable(hook string) error {
+	return setHookDisabled(hook, false)
+}
+
+// disable turns a hook off, keeping its definition. The setting is saved in the
+// config so every install of the repository generates a shim doing nothing
+func disable(hook string) error {
+	return setHookDisabled(hook, true)
+}
+
+// setHookDisabled saves the disabled state of a hook and regenerates its shim
+func setHookDisabled(hook string, disabled bool) error {
+	if err := checkHookCommand(hook); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	declaration, ok := config.Hooks[hook]
+	if !ok {
+		return fmt.Errorf("hook '%s' is not defined in %s", hook, config.Path())
+	}
+	if declaration == nil {
+		declaration = config.Hook(hook)
+	}
+	declaration.Disabled = disabled
+
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	content := renderHookScript(hook, declaration, config)
+	if err := createHook(tools.GetHuskyHooksDir(true), hook, content, config); err != nil {
+		return fmt.Errorf("failed to create hook: %w", err)
+	}
+
+	return nil
+}
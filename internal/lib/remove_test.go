@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupHooks(t *testing.T) {
+	t.Helper()
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - run: exit 1
+  pre-push:
+    commands:
+      - run: go vet ./...
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(InstallOptions{Quiet: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDisableEnable(t *testing.T) {
+	setupHooks(t)
+
+	assert.NoError(t, Disable("pre-commit"))
+	assert.NoError(t, Install(InstallOptions{Quiet: true}))
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, config.Hooks["pre-commit"].Disabled)
+	assert.Len(t, config.Hooks["pre-commit"].Commands, 1)
+
+	// the installed shim exits before running husky
+	content, _ := os.ReadFile(".git/hooks/pre-commit")
+	assert.Contains(t, string(content), disabledMarker)
+	cmd := exec.Command(".git/hooks/pre-commit")
+	cmd.Env = append(os.Environ(), "PATH=/nonexistent")
+	assert.NoError(t, cmd.Run())
+
+	assert.NoError(t, Enable("pre-commit"))
+	assert.NoError(t, Install(InstallOptions{Quiet: true}))
+
+	config, _ = LoadConfig()
+	assert.False(t, config.Hooks["pre-commit"].Disabled)
+	content, _ = os.ReadFile(".git/hooks/pre-commit")
+	assert.NotContains(t, string(content), disabledMarker)
+
+	assert.Error(t, Disable("post-merge"))
+	assert.Error(t, Disable("hook-invalido"))
+}
+
+func TestRemove(t *testing.T) {
+	setupHooks(t)
+
+	// a hook chained with the husky one is put back
+	os.Rename(".git/hooks/pre-push", ".git/hooks/pre-push"+chainedSuffix)
+	os.WriteFile(".git/hooks/pre-push"+chainedSuffix, []byte("#!/bin/sh\necho previous\n"), 0755)
+	os.WriteFile(".git/hooks/pre-push", []byte(renderChainScript("pre-push", ".husky/hooks/pre-push")), 0755)
+
+	assert.NoError(t, Remove("pre-push", RemoveOptions{}))
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.NotContains(t, config.Hooks, "pre-push")
+	assert.Contains(t, config.Hooks, "pre-commit")
+
+	assert.NoFileExists(t, ".husky/hooks/pre-push")
+	assert.NoFileExists(t, ".git/hooks/pre-push"+chainedSuffix)
+	content, _ := os.ReadFile(".git/hooks/pre-push")
+	assert.Equal(t, "#!/bin/sh\necho previous\n", string(content))
+
+	assert.NoError(t, Install(InstallOptions{Quiet: true}))
+	assert.FileExists(t, ".git/hooks/pre-commit")
+
+	assert.Error(t, Remove("pre-push", RemoveOptions{}))
+}
@@ -0,0 +1,174 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// gitIndexMu serializes the commands updating the git index
+var gitIndexMu sync.Mutex
+
+// unstagedStash holds the unstaged changes of partially staged files while the hook runs
+type unstagedStash struct {
+	patch    string            // file holding the unstaged changes
+	files    []string          // files whose unstaged changes were removed from the working tree
+	tree     string            // index before the commands ran
+	contents map[string][]byte // working tree content of the files before the commands ran
+}
+
+// stashUnstaged saves the unstaged changes of the partially staged files among files
+// to a patch and removes them from the working tree, so the commands only see staged content
+func stashUnstaged(files []string) (*unstagedStash, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	out, err := tools.Git(append([]string{"diff", "--name-only", "-z", "--"}, files...)...)
+	if err != nil {
+		return nil, err
+	}
+	partial := tools.SplitNul(out)
+	if len(partial) == 0 {
+		return nil, nil
+	}
+
+	gitDir, err := tools.Git("rev-parse", "--git-dir")
+	if err != nil {
+		return nil, err
+	}
+	patch := filepath.Join(strings.TrimSpace(gitDir), "husky", "unstaged.patch")
+	if _, err := os.Stat(patch); err == nil {
+		return nil, fmt.Errorf("unstaged changes from a previous run were not restored, apply %s with 'git apply' and remove it", patch)
+	}
+
+	diff, err := tools.Git(append([]string{"diff", "--binary", "--no-color", "--no-ext-diff", "--"}, partial...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// snapshot the index and the files, to put them back if the unstaged changes cannot be reapplied
+	tree, err := tools.Git("write-tree")
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string][]byte, len(partial))
+	for _, file := range partial {
+		if contents[file], err = os.ReadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(patch), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(patch, []byte(diff), 0644); err != nil {
+		return nil, fmt.Errorf("failed to save unstaged changes: %w", err)
+	}
+
+	if _, err := tools.Git(append([]string{"checkout", "--"}, partial...)...); err != nil {
+		return nil, fmt.Errorf("failed to hide unstaged changes, they were saved in %s: %w", patch, err)
+	}
+
+	tools.LogDebug("hid unstaged changes of %s", strings.Join(partial, ", "))
+	return &unstagedStash{patch: patch, files: partial, tree: strings.TrimSpace(tree), contents: contents}, nil
+}
+
+// restore reapplies the unstaged changes. When the commands changed lines the unstaged changes
+// conflict with, the index and the files are put back as they were before the commands ran
+func (s *unstagedStash) restore() error {
+	if s == nil {
+		return nil
+	}
+
+	if _, err := tools.Git("apply", "--whitespace=nowarn", s.patch); err != nil {
+		// the commands changed the context of the unstaged hunks, fall back to a three-way
+		// merge, which also updates the index, and put back the index written by the hook
+		tree, err := tools.Git("write-tree")
+		if err != nil {
+			return fmt.Errorf("failed to restore unstaged changes, they were saved in %s: %w", s.patch, err)
+		}
+		if _, err3 := tools.Git("apply", "--whitespace=nowarn", "--3way", s.patch); err3 != nil {
+			if err := s.rollback(); err != nil {
+				return fmt.Errorf("failed to restore unstaged changes of %s, they were saved in %s: %w",
+					strings.Join(s.files, ", "), s.patch, err)
+			}
+			os.Remove(s.patch)
+			return fmt.Errorf("the changes of the stage_fixed commands conflict with the unstaged changes of %s and were not applied: %w",
+				strings.Join(s.files, ", "), err3)
+		}
+		if _, err := tools.Git("read-tree", strings.TrimSpace(tree)); err != nil {
+			return fmt.Errorf("failed to restore the index: %w", err)
+		}
+		tools.Git("update-index", "-q", "--refresh")
+	}
+
+	return os.Remove(s.patch)
+}
+
+// rollback puts back the index and the partially staged files as they were before the commands ran
+func (s *unstagedStash) rollback() error {
+	if _, err := tools.Git("read-tree", s.tree); err != nil {
+		return fmt.Errorf("failed to restore the index: %w", err)
+	}
+	for _, file := range s.files {
+		if err := os.WriteFile(file, s.contents[file], 0644); err != nil {
+			return err
+		}
+	}
+	tools.Git("update-index", "-q", "--refresh")
+	return nil
+}
+
+// fileDigests returns the SHA-256 of the content of each file, empty for missing files
+func fileDigests(files []string) map[string]string {
+	digests := make(map[string]string, len(files))
+	for _, file := range files {
+		digests[file] = fileDigest(file)
+	}
+	return digests
+}
+
+func fileDigest(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// restageFixed adds back to the index the files changed since the digests were taken
+func restageFixed(before map[string]string) ([]string, error) {
+	changed := []string{}
+	for file, digest := range before {
+		if fileDigest(file) != digest {
+			changed = append(changed, file)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	sort.Strings(changed)
+
+	gitIndexMu.Lock()
+	defer gitIndexMu.Unlock()
+
+	if _, err := tools.Git(append([]string{"add", "--"}, changed...)...); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunStageFixed(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - name: fix
+        run: sed -i.bak 's/bad/good/' {staged_files} && rm -f *.bak
+        glob: ["*.txt"]
+        stage_fixed: true
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+
+	os.WriteFile("full.txt", []byte("line1\n"), 0644)
+	os.WriteFile("partial.txt", []byte("line1\nline2\n"), 0644)
+	os.WriteFile("untouched.txt", []byte("line1\n"), 0644)
+	gitCmd(t, "add", ".")
+	gitCmd(t, "commit", "-q", "-m", "initial")
+
+	// full.txt is completely staged, partial.txt has unstaged changes next to the fixed line
+	os.WriteFile("full.txt", []byte("bad\n"), 0644)
+	os.WriteFile("partial.txt", []byte("bad1\nline2\n"), 0644)
+	os.WriteFile("untouched.txt", []byte("ok\n"), 0644)
+	gitCmd(t, "add", ".")
+	os.WriteFile("partial.txt", []byte("bad1\nline2\nunstaged\n"), 0644)
+
+	stdout := new(bytes.Buffer)
+	result, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: stdout, Quiet: true})
+	assert.NoError(t, err)
+	assert.False(t, result.Failed(), stdout.String())
+	assert.Contains(t, stdout.String(), "[fix] restaged full.txt, partial.txt")
+
+	// the fixes are staged
+	assert.Equal(t, "good\n", gitCmd(t, "show", ":full.txt"))
+	assert.Equal(t, "good1\nline2\n", gitCmd(t, "show", ":partial.txt"))
+	assert.Equal(t, "ok\n", gitCmd(t, "show", ":untouched.txt"))
+
+	// the unstaged changes are back in the working tree and still unstaged
+	content, _ := os.ReadFile("partial.txt")
+	assert.Equal(t, "good1\nline2\nunstaged\n", string(content))
+	assert.Equal(t, "partial.txt\n", gitCmd(t, "diff", "--name-only"))
+
+	_, err = os.Stat(".git/husky/unstaged.patch")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunStageFixedConflict(t *testing.T) {
+	initGitRepo(t)
+
+	os.MkdirAll(".husky", 0755)
+	config := `hooks:
+  pre-commit:
+    commands:
+      - name: fix
+        run: sed -i.bak 's/bad/good/' {staged_files} && rm -f *.bak
+        glob: ["*.txt"]
+        stage_fixed: true
+`
+	os.WriteFile(".husky/husky.yaml", []byte(config), 0644)
+	os.WriteFile("partial.txt", []byte("one\ntwo\nthree\n"), 0644)
+	gitCmd(t, "add", ".")
+	gitCmd(t, "commit", "-q", "-m", "initial")
+
+	// the fixed line is next to an unstaged hunk, the unstaged changes cannot be merged back
+	os.WriteFile("partial.txt", []byte("one\nbad\nthree\n"), 0644)
+	gitCmd(t, "add", "partial.txt")
+	os.WriteFile("partial.txt", []byte("one\nbad\nthree unstaged\n"), 0644)
+
+	stdout := new(bytes.Buffer)
+	_, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: stdout, Quiet: true})
+	assert.ErrorContains(t, err, "the changes of the stage_fixed commands conflict with the unstaged changes of partial.txt and were not applied")
+
+	// the index and the file are back as they were, without conflicts
+	assert.Equal(t, "one\nbad\nthree\n", gitCmd(t, "show", ":partial.txt"))
+	content, _ := os.ReadFile("partial.txt")
+	assert.Equal(t, "one\nbad\nthree unstaged\n", string(content))
+	assert.Empty(t, gitCmd(t, "ls-files", "-u"))
+
+	_, err = os.Stat(".git/husky/unstaged.patch")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,492 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// RunOptions are the options for the run command
+type RunOptions struct {
+	Hook   string    // Hook to run
+	Args   []string  // Arguments passed by git to the hook
+	Stdin  io.Reader // Input passed by git to the hook
+	Stdout io.Writer // Output of the commands, defaults to os.Stdout
+	Stderr io.Writer // Error output of the commands, defaults to os.Stderr
+	Quiet  bool      // Quiet mode
+}
+
+// CommandResult is the outcome of a single command of a hook
+type CommandResult struct {
+	Name       string        // Display name of the command
+	ExitCode   int           // Exit code, -1 if the command could not be started
+	Duration   time.Duration // Time spent running the command
+	Err        error         // Error returned by the command
+	Skipped    bool          // Whether the command was not executed
+	SkipReason string        // Why the command was skipped
+
+	blocked bool // skipped because a command it depends on did not succeed
+}
+
+// Failed reports whether the command was executed and failed
+func (r *CommandResult) Failed() bool {
+	return !r.Skipped && r.Err != nil
+}
+
+// RunResult is the outcome of running a hook
+type RunResult struct {
+	Hook     string
+	Commands []*CommandResult
+	Duration time.Duration
+}
+
+// Failed reports whether any command of the hook failed
+func (r *RunResult) Failed() bool {
+	for _, command := range r.Commands {
+		if command.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns the exit code of the first failed command, 0 if none failed
+func (r *RunResult) ExitCode() int {
+	for _, command := range r.Commands {
+		if command.Failed() {
+			if command.ExitCode > 0 {
+				return command.ExitCode
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Summary returns a human readable summary of the hook execution
+func (r *RunResult) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n husky > %s (%s)\n", r.Hook, formatDuration(r.Duration)))
+	for _, command := range r.Commands {
+		switch {
+		case command.Skipped:
+			sb.WriteString(fmt.Sprintf("   ⏭️  %s: skipped, %s\n", command.Name, command.SkipReason))
+		case command.Failed():
+			sb.WriteString(fmt.Sprintf("   ❌ %s: exit code %d (%s)\n", command.Name, command.ExitCode, formatDuration(command.Duration)))
+		default:
+			sb.WriteString(fmt.Sprintf("   ✅ %s (%s)\n", command.Name, formatDuration(command.Duration)))
+		}
+	}
+	return sb.String()
+}
+
+// exported functions
+var (
+	Run = run
+)
+
+// run executes the commands declared for a hook in the config
+func run(opts RunOptions) (*RunResult, error) {
+	if !tools.IsValidHook(opts.Hook) {
+		return nil, errors.New("invalid hook")
+	}
+
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	// git runs hooks from the root of the working tree, do the same when called by hand
+	if repo, err := tools.FindRepository(); err == nil {
+		if err := os.Chdir(repo.WorkTree); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{Hook: opts.Hook}
+
+	if reason := hookSkipReason(opts.Hook, config); reason != "" {
+		if !opts.Quiet {
+			fmt.Fprint(opts.Stdout, skippedSummary(opts.Hook, reason))
+		}
+		return result, nil
+	}
+	skip := envList("SKIP")
+
+	packages, err := loadPackages(config)
+	if err != nil {
+		return nil, err
+	}
+	units := append([]*Package{{Config: config}}, packages...)
+
+	// git passes the input only once, every command receives a copy of it
+	var stdin []byte
+	if opts.Stdin != nil {
+		if stdin, err = io.ReadAll(opts.Stdin); err != nil {
+			return nil, fmt.Errorf("failed to read hook input: %w", err)
+		}
+	}
+
+	// each unit is either run with its tasks, or skipped as a whole
+	type unitRun struct {
+		hook    *HookConfig
+		tasks   []*task
+		skipped *CommandResult
+	}
+
+	input, err := parseHookInput(opts.Hook, opts.Args, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	files := newFileSets(input)
+	runs := []*unitRun{}
+	for _, unit := range units {
+		hook, ok := unit.Config.Hooks[opts.Hook]
+		if !ok || hook == nil || len(hook.Commands) == 0 {
+			continue
+		}
+		if hook.Disabled {
+			tools.LogDebug("hook '%s' is disabled in %s", opts.Hook, unit.Config.Path())
+			continue
+		}
+
+		// a package only runs when the commit or push changes files below it
+		if unit.Dir != "" && (opts.Hook == "pre-commit" || opts.Hook == "pre-push") {
+			placeholder := defaultFilesPlaceholder(opts.Hook)
+			changed, err := files.get(placeholder)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", filesDescription(placeholder), err)
+			}
+			if !containsAny(unit, changed) {
+				runs = append(runs, &unitRun{skipped: &CommandResult{
+					Name:       unit.Dir,
+					Skipped:    true,
+					SkipReason: fmt.Sprintf("no %s under %s", filesDescription(placeholder), unit.Dir),
+				}})
+				continue
+			}
+		}
+
+		unitTasks := make([]*task, len(hook.Commands))
+		for i, command := range hook.Commands {
+			if unitTasks[i], err = prepareTask(opts.Hook, command, files, unit); err != nil {
+				return nil, err
+			}
+			unitTasks[i].run = input.expand(unitTasks[i].run)
+			if reason := stepSkipReason(unitTasks[i], unit.Config, skip); reason != "" {
+				unitTasks[i].skipReason = reason
+			}
+		}
+		runs = append(runs, &unitRun{hook: hook, tasks: unitTasks})
+	}
+
+	if len(runs) == 0 {
+		tools.LogDebug("no commands declared for hook '%s'", opts.Hook)
+		return result, nil
+	}
+
+	// commands fixing files must only see, and restage, the staged content
+	fixedFiles := []string{}
+	for _, unit := range runs {
+		for _, t := range unit.tasks {
+			if t.command.StageFixed && t.skipReason == "" {
+				fixedFiles = append(fixedFiles, t.files...)
+			}
+		}
+	}
+	stash, err := stashUnstaged(fixedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	for _, unit := range runs {
+		if unit.skipped != nil {
+			result.Commands = append(result.Commands, unit.skipped)
+			continue
+		}
+		result.Commands = append(result.Commands, runCommands(unit.hook, unit.tasks, opts, input)...)
+	}
+	result.Duration = time.Since(start)
+
+	if err := stash.restore(); err != nil {
+		return nil, err
+	}
+
+	if !opts.Quiet {
+		fmt.Fprint(opts.Stdout, result.Summary())
+	}
+
+	return result, nil
+}
+
+// containsAny reports whether any of the files belongs to the package
+func containsAny(pkg *Package, files []string) bool {
+	for _, file := range files {
+		if pkg.contains(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandOutput holds the output of a command run in parallel until it can be printed
+type commandOutput struct {
+	stdout, stderr bytes.Buffer
+}
+
+// runCommands executes the commands of a hook respecting their dependencies.
+// Sequential hooks stream the output and stop at the first failure, parallel hooks
+// buffer the output of each command and print it in declaration order
+func runCommands(hook *HookConfig, tasks []*task, opts RunOptions, input *HookInput) []*CommandResult {
+	commands := hook.Commands
+	results := make([]*CommandResult, len(commands))
+	outputs := make([]*commandOutput, len(commands))
+	started := make([]bool, len(commands))
+	workers := hook.MaxWorkers()
+
+	byName := map[string]int{}
+	for i, command := range commands {
+		if command.Name != "" {
+			byName[command.Name] = i
+		}
+	}
+
+	type finishedCommand struct {
+		index  int
+		result *CommandResult
+	}
+
+	var mu sync.Mutex
+	done := make(chan finishedCommand)
+	running, finished, printed := 0, 0, 0
+	failed := false
+
+	// print the buffered output of the finished commands, keeping the declaration order
+	flush := func() {
+		for printed < len(commands) && results[printed] != nil {
+			if output := outputs[printed]; output != nil {
+				opts.Stdout.Write(output.stdout.Bytes())
+				opts.Stderr.Write(output.stderr.Bytes())
+			}
+			printed++
+		}
+	}
+
+	skip := func(i int, reason string, blocked bool) {
+		results[i] = &CommandResult{Name: tasks[i].name, Skipped: true, SkipReason: reason, blocked: blocked}
+		started[i] = true
+		finished++
+	}
+
+	for finished < len(commands) {
+		for scheduled := true; scheduled; {
+			scheduled = false
+			for i, command := range commands {
+				if started[i] {
+					continue
+				}
+
+				if failed && !hook.Parallel {
+					skip(i, "a previous command failed", true)
+					scheduled = true
+					continue
+				}
+
+				if tasks[i].skipReason != "" {
+					skip(i, tasks[i].skipReason, false)
+					scheduled = true
+					continue
+				}
+
+				ready := true
+				for _, need := range command.Needs {
+					dependency := results[byName[need]]
+					if dependency == nil {
+						ready = false
+						continue
+					}
+					if dependency.blocked || dependency.Failed() {
+						skip(i, fmt.Sprintf("needs '%s' which did not succeed", need), true)
+						scheduled = true
+						break
+					}
+				}
+				if started[i] || !ready || running >= workers {
+					continue
+				}
+
+				started[i] = true
+				running++
+				scheduled = true
+
+				stdout, stderr := opts.Stdout, opts.Stderr
+				if hook.Parallel {
+					outputs[i] = &commandOutput{}
+					stdout, stderr = &outputs[i].stdout, &outputs[i].stderr
+				}
+
+				go func(i int) {
+					done <- finishedCommand{i, runCommand(tasks[i], opts, input, stdout, stderr, &mu)}
+				}(i)
+			}
+		}
+		flush()
+
+		if running == 0 {
+			break
+		}
+
+		command := <-done
+		results[command.index] = command.result
+		running--
+		finished++
+		if command.result.Failed() {
+			failed = true
+		}
+		flush()
+	}
+
+	return results
+}
+
+// runCommand executes a single command, writing its output with the command name as prefix
+func runCommand(t *task, opts RunOptions, input *HookInput, out, errOut io.Writer, mu *sync.Mutex) *CommandResult {
+	result := &CommandResult{Name: t.name}
+	prefix := fmt.Sprintf("[%s] ", result.Name)
+
+	stdout := newPrefixWriter(out, prefix, mu)
+	stderr := newPrefixWriter(errOut, prefix, mu)
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	var digests map[string]string
+	if t.command.StageFixed {
+		digests = fileDigests(t.files)
+	}
+
+	start := time.Now()
+	if t.command.Builtin != "" {
+		result.Err = runBuiltin(t, input, stdout, stderr)
+	} else {
+		// the hook arguments are available to the command as $1, $2...
+		args := append([]string{"-c", t.run, "husky-" + opts.Hook}, opts.Args...)
+		cmd := exec.Command("sh", args...)
+		cmd.Dir = t.dir
+		cmd.Stdin = bytes.NewReader(input.Stdin)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		cmd.Env = append(os.Environ(), input.Env()...)
+		result.Err = cmd.Run()
+	}
+	result.Duration = time.Since(start)
+
+	if result.Err == nil && digests != nil {
+		restaged, err := restageFixed(digests)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to restage fixed files: %w", err)
+		} else if len(restaged) > 0 {
+			fmt.Fprintf(stdout, "restaged %s\n", strings.Join(restaged, ", "))
+		}
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case result.Err == nil:
+		result.ExitCode = 0
+	case errors.As(result.Err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case t.command.Builtin != "":
+		result.ExitCode = 1
+		fmt.Fprintf(stderr, "%v\n", result.Err)
+	default:
+		result.ExitCode = -1
+		fmt.Fprintf(stderr, "%v\n", result.Err)
+	}
+
+	return result
+}
+
+// DisplayName returns the name used to identify the command in the output
+func (c *HookCommand) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if c.Builtin != "" {
+		return c.Builtin
+	}
+	name := strings.TrimSpace(strings.SplitN(c.Run, "\n", 2)[0])
+	if len(name) > 30 {
+		name = name[:27] + "..."
+	}
+	return name
+}
+
+// formatDuration formats a duration with a precision suitable for the summary
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// prefixWriter writes each line it receives prefixed, holding incomplete lines until they are finished
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(out io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{out: out, prefix: prefix, mu: mu}
+}
+
+// Write implements io.Writer
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the pending incomplete line, if any
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n')
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+	return err
+}
@@ -0,0 +1,167 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky", 0755)
+
+	config := `hooks:
+  commit-msg:
+    commands:
+      - run: echo "file $1"
+      - run: cat
+  pre-push:
+    commands:
+      - run: echo first
+      - run: echo broken >&2; exit 3
+      - run: echo never
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       RunOptions
+		wantErr    bool
+		wantFailed bool
+		wantCode   int
+		wantOut    []string
+		wantErrOut []string
+		wantCount  int
+	}{
+		{
+			name: "Arguments and input are passed to every command",
+			opts: RunOptions{
+				Hook:  "commit-msg",
+				Args:  []string{".git/COMMIT_EDITMSG"},
+				Stdin: strings.NewReader("input\n"),
+			},
+			wantOut:   []string{`[echo "file $1"] file .git/COMMIT_EDITMSG`, "[cat] input", "✅ cat"},
+			wantCount: 2,
+		},
+		{
+			name:       "Failed command stops the hook",
+			opts:       RunOptions{Hook: "pre-push"},
+			wantFailed: true,
+			wantCode:   3,
+			wantOut:    []string{"[echo first] first", "❌ echo broken >&2; exit 3: exit code 3", "echo never: skipped"},
+			wantErrOut: []string{"[echo broken >&2; exit 3] broken"},
+			wantCount:  3,
+		},
+		{
+			name:      "Hook without commands",
+			opts:      RunOptions{Hook: "post-merge"},
+			wantCount: 0,
+		},
+		{
+			name:    "Invalid hook",
+			opts:    RunOptions{Hook: "hook-invalido"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			tt.opts.Stdout = stdout
+			tt.opts.Stderr = stderr
+
+			result, err := Run(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFailed, result.Failed())
+			assert.Equal(t, tt.wantCode, result.ExitCode())
+			assert.Len(t, result.Commands, tt.wantCount)
+			for _, out := range tt.wantOut {
+				assert.Contains(t, stdout.String(), out)
+			}
+			for _, out := range tt.wantErrOut {
+				assert.Contains(t, stderr.String(), out)
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var mu sync.Mutex
+	out := new(bytes.Buffer)
+	w := newPrefixWriter(out, "[test] ", &mu)
+
+	w.Write([]byte("first line\nsecond "))
+	w.Write([]byte("line\nunfinished"))
+	w.Flush()
+
+	assert.Equal(t, "[test] first line\n[test] second line\n[test] unfinished\n", out.String())
+}
+
+func TestRunParallel(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky", 0755)
+
+	config := `hooks:
+  pre-push:
+    parallel: true
+    workers: 2
+    commands:
+      - name: slow
+        run: sleep 0.2; echo slow
+      - name: fast
+        run: echo fast
+      - name: after
+        run: echo after
+        needs: [slow, fast]
+      - name: broken
+        run: exit 1
+      - name: blocked
+        run: echo blocked
+        needs: [broken]
+`
+	if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	result, err := Run(RunOptions{Hook: "pre-push", Stdout: stdout, Stderr: new(bytes.Buffer), Quiet: true})
+	assert.NoError(t, err)
+	assert.True(t, result.Failed())
+
+	// output is printed in declaration order even though fast finishes first
+	assert.Equal(t, "[slow] slow\n[fast] fast\n[after] after\n", stdout.String())
+
+	names := []string{}
+	for _, command := range result.Commands {
+		names = append(names, command.Name)
+	}
+	assert.Equal(t, []string{"slow", "fast", "after", "broken", "blocked"}, names)
+	assert.False(t, result.Commands[2].Skipped)
+	assert.True(t, result.Commands[3].Failed())
+	assert.True(t, result.Commands[4].Skipped)
+	assert.Contains(t, result.Commands[4].SkipReason, "broken")
+}
@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// disabledMarker identifies the shims of disabled hooks, which exit before running husky
+const disabledMarker = "# husky:disabled"
+
+// renderHookScript renders the shim installed for a hook declared in the config,
+// which delegates the execution of its commands to "husky run"
+func renderHookScript(name string, hook *HookConfig, config *HuskyConfig) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(fmt.Sprintf("# Husky %s hook\n", name))
+	sb.WriteString(fmt.Sprintf("# Generated from %s, do not edit by hand.\n", filepath.ToSlash(config.Path())))
+	sb.WriteString("\n")
+	if hook != nil && hook.Disabled {
+		sb.WriteString(fmt.Sprintf("%s, run 'husky enable %s' to enable it again.\n", disabledMarker, name))
+		sb.WriteString("exit 0\n\n")
+	}
+	sb.WriteString("if [ \"$HUSKY\" = \"0\" ]; then\n")
+	sb.WriteString("    exit 0\n")
+	sb.WriteString("fi\n")
+	sb.WriteString("case \",$HUSKY_SKIP_HOOKS,\" in\n")
+	sb.WriteString(fmt.Sprintf("    *\",%s,\"*) exit 0 ;;\n", name))
+	sb.WriteString("esac\n\n")
+	sb.WriteString("if ! command -v husky >/dev/null 2>&1; then\n")
+	// a missing binary must not let blocking hooks pass silently, HUSKY=0 skips them explicitly
+	sb.WriteString(fmt.Sprintf("    echo \"husky: command not found, cannot run the %s hook.\" >&2\n", name))
+	sb.WriteString("    echo \"husky: install husky, or set HUSKY=0 to skip the hooks.\" >&2\n")
+	sb.WriteString("    exit 127\n")
+	sb.WriteString("fi\n\n")
+	sb.WriteString(fmt.Sprintf("exec husky run %s \"$@\"\n", name))
+
+	return sb.String()
+}
+
+// writeHookScripts writes the scripts of all hooks declared in the config, or in the
+// config of any of the packages, to the husky hooks directory
+func writeHookScripts(huskyHooksDir string, config *HuskyConfig, packages ...*Package) error {
+	if err := os.MkdirAll(huskyHooksDir, os.FileMode(config.DefaultPermissions)); err != nil {
+		return err
+	}
+
+	hooks := map[string]*HookConfig{}
+	for _, pkg := range packages {
+		for name, hook := range pkg.Config.Hooks {
+			hooks[name] = hook
+		}
+	}
+	for name, hook := range config.Hooks {
+		hooks[name] = hook
+	}
+
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := renderHookScript(name, hooks[name], config)
+		if err := createHook(huskyHooksDir, name, content, config); err != nil {
+			return fmt.Errorf("failed to create %s hook: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,631 @@
+package lib
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// exported functions
+var (
+	ScanSecrets = scanSecretsCommand
+)
+
+// Formats of the secret scanning report
+const (
+	SecretsText  = "text"
+	SecretsJSON  = "json"
+	SecretsSARIF = "sarif"
+)
+
+// SecretsFormats are the supported report formats
+var SecretsFormats = []string{SecretsText, SecretsJSON, SecretsSARIF}
+
+// DefaultSecretsAllowlist is the allowlist used when the step does not name one
+const DefaultSecretsAllowlist = ".husky/secrets-allowlist"
+
+// allowSecretAnnotation marks a line whose findings are false positives
+const allowSecretAnnotation = "husky:allow-secret"
+
+// secretRule is a kind of credential the scanner looks for
+type secretRule struct {
+	id          string
+	description string
+	pattern     *regexp.Regexp // the secret is the first group if any, the whole match otherwise
+	entropy     float64        // minimum Shannon entropy of the secret in bits per character, 0 for none
+	plain       bool           // the match is a marker, not the secret itself, it is not redacted
+}
+
+// secretRules are the rules, the most specific first: a match overlapping an earlier one is ignored
+var secretRules = []secretRule{
+	{
+		id:          "private-key",
+		description: "Private key",
+		pattern:     regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+		plain:       true,
+	},
+	{
+		id:          "aws-access-key-id",
+		description: "AWS access key ID",
+		pattern:     regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`),
+	},
+	{
+		id:          "aws-secret-access-key",
+		description: "AWS secret access key",
+		pattern:     regexp.MustCompile(`(?i)aws[\w-]*(?:secret|sk)[\w-]*["']?\s*(?::=|=>|[:=])\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		entropy:     4,
+	},
+	{
+		id:          "github-token",
+		description: "GitHub token",
+		pattern:     regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`),
+	},
+	{
+		id:          "slack-token",
+		description: "Slack token",
+		pattern:     regexp.MustCompile(`\b(xox[abprs]-[A-Za-z0-9-]{10,})\b`),
+	},
+	{
+		id:          "stripe-key",
+		description: "Stripe secret key",
+		pattern:     regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{24,})\b`),
+	},
+	{
+		id:          "google-api-key",
+		description: "Google API key",
+		pattern:     regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`),
+	},
+	{
+		id:          "jwt",
+		description: "JSON Web Token",
+		pattern:     regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`),
+	},
+	{
+		id:          "generic-secret",
+		description: "Secret assigned to a variable",
+		pattern:     regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|credential|access[_-]?key)[\w-]*["']?\s*(?::=|=>|[:=])\s*["']?([A-Za-z0-9_\-+/=]{16,})`),
+		entropy:     3.5,
+	},
+}
+
+// addedLine is a line added by the staged changes or by a pushed commit
+type addedLine struct {
+	file   string
+	number int
+	text   string
+	commit string // commit adding the line, empty for the staged changes
+}
+
+// secretFinding is a secret found in an added line
+type secretFinding struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Commit      string `json:"commit,omitempty"`
+	Secret      string `json:"secret"` // redacted
+	Fingerprint string `json:"fingerprint"`
+}
+
+// secretAllowlist are the findings known to be false positives
+type secretAllowlist struct {
+	paths        []string         // globs of the files not scanned
+	rules        []string         // rules turned off
+	fingerprints []string         // fingerprints of the allowed secrets
+	patterns     []*regexp.Regexp // regular expressions of the allowed secrets
+}
+
+// loadSecretAllowlist reads an allowlist, one entry per line: path:<glob>, rule:<id>,
+// fingerprint:<fingerprint>, or a regular expression matched against the secret.
+// A missing file is an empty allowlist unless it is required
+func loadSecretAllowlist(file string, required bool) (*secretAllowlist, error) {
+	allow := &secretAllowlist{}
+	f, err := os.Open(filepath.FromSlash(file))
+	if os.IsNotExist(err) && !required {
+		return allow, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for number := 1; scanner.Scan(); number++ {
+		entry := strings.TrimSpace(scanner.Text())
+		switch {
+		case entry == "" || strings.HasPrefix(entry, "#"):
+		case strings.HasPrefix(entry, "path:"):
+			allow.paths = append(allow.paths, strings.TrimSpace(strings.TrimPrefix(entry, "path:")))
+		case strings.HasPrefix(entry, "rule:"):
+			allow.rules = append(allow.rules, strings.TrimSpace(strings.TrimPrefix(entry, "rule:")))
+		case strings.HasPrefix(entry, "fingerprint:"):
+			allow.fingerprints = append(allow.fingerprints, strings.TrimSpace(strings.TrimPrefix(entry, "fingerprint:")))
+		default:
+			pattern, err := regexp.Compile(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid pattern: %w", file, number, err)
+			}
+			allow.patterns = append(allow.patterns, pattern)
+		}
+	}
+	return allow, scanner.Err()
+}
+
+// allowed reports whether a secret found by a rule is in the allowlist
+func (a *secretAllowlist) allowed(rule, secret, fingerprint string) bool {
+	if contains(a.rules, rule) || contains(a.fingerprints, fingerprint) {
+		return true
+	}
+	for _, pattern := range a.patterns {
+		if pattern.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddedLines returns the added lines of a unified diff with no context, from git diff or
+// git log -p with the commits printed as "commit <sha>"
+func parseAddedLines(diff string) []addedLine {
+	lines := []addedLine{}
+	commit, file := "", ""
+	number, oldLeft, newLeft := 0, 0, 0
+
+	for _, text := range strings.Split(diff, "\n") {
+		// inside a hunk, the counts of its header tell where it ends
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if file != "" {
+					lines = append(lines, addedLine{file: file, number: number, text: text[1:], commit: commit})
+				}
+				number++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, " "):
+				number++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "commit "):
+			commit = strings.TrimSpace(strings.TrimPrefix(text, "commit "))
+		case strings.HasPrefix(text, "diff "):
+			file = ""
+		case strings.HasPrefix(text, "+++ "):
+			file = diffPath(strings.TrimPrefix(text, "+++ "))
+		case strings.HasPrefix(text, "@@ "):
+			number, oldLeft, newLeft = parseHunkHeader(text)
+		}
+	}
+	return lines
+}
+
+// parseHunkHeader returns the first new line and the old and new line counts of a hunk
+func parseHunkHeader(header string) (int, int, int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0, 0
+	}
+	_, oldCount := hunkRange(strings.TrimPrefix(fields[1], "-"))
+	start, newCount := hunkRange(strings.TrimPrefix(fields[2], "+"))
+	return start, oldCount, newCount
+}
+
+// hunkRange parses the start,count range of a hunk header, the count defaults to 1
+func hunkRange(r string) (int, int) {
+	start, count, found := strings.Cut(r, ",")
+	s, _ := strconv.Atoi(start)
+	if !found {
+		return s, 1
+	}
+	c, _ := strconv.Atoi(count)
+	return s, c
+}
+
+// diffPath returns the path of a +++ line, empty for a deleted file
+func diffPath(name string) string {
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, "b/")
+}
+
+// diffArgs are the options giving a diff parseAddedLines understands, whatever the git config
+var diffArgs = []string{"-U0", "--no-color", "--no-ext-diff", "--no-textconv", "--src-prefix=a/", "--dst-prefix=b/", "--diff-filter=ACMR"}
+
+// stagedAdditions returns the lines added by the staged changes
+func stagedAdditions() ([]addedLine, error) {
+	out, err := tools.Git(append([]string{"-c", "core.quotePath=false", "diff", "--cached"}, diffArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseAddedLines(out), nil
+}
+
+// logAdditions returns the lines added by the commits of a revision range
+func logAdditions(revisions ...string) ([]addedLine, error) {
+	args := append([]string{"-c", "core.quotePath=false", "log", "-p", "--format=commit %H"}, diffArgs...)
+	out, err := tools.Git(append(args, revisions...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseAddedLines(out), nil
+}
+
+// pushAdditions returns the lines added by the pushed commits, falling back to the commits
+// not pushed to the upstream branch when the refs are unknown
+func pushAdditions(refs []PushRef) ([]addedLine, error) {
+	if len(refs) == 0 {
+		if lines, err := logAdditions("@{upstream}..HEAD"); err == nil {
+			return lines, nil
+		}
+		return logAdditions("HEAD", "--not", "--remotes")
+	}
+
+	lines := []addedLine{}
+	for _, ref := range refs {
+		if ref.IsDelete() {
+			continue
+		}
+
+		var added []addedLine
+		var err error
+		if ref.IsNew() {
+			added, err = logAdditions(ref.LocalOID, "--not", "--remotes")
+		} else if added, err = logAdditions(ref.RemoteOID + ".." + ref.LocalOID); err != nil {
+			// the remote commit is unknown locally, e.g. after a force push from elsewhere
+			added, err = logAdditions(ref.LocalOID, "--not", "--remotes")
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, added...)
+	}
+	return lines, nil
+}
+
+// shannonEntropy returns the Shannon entropy of a string in bits per character
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// secretFingerprint identifies a secret found by a rule, wherever it is
+func secretFingerprint(rule, secret string) string {
+	sum := sha256.Sum256([]byte(rule + "\x00" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// redact hides all but the first characters of a secret
+func redact(secret string) string {
+	if len(secret) < 12 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", min(len(secret)-4, 16))
+}
+
+// scanSecrets returns the secrets found in the added lines, once per file, line and secret
+func scanSecrets(lines []addedLine, allow *secretAllowlist) []secretFinding {
+	findings := []secretFinding{}
+	seen := map[string]bool{}
+
+	for _, line := range lines {
+		if strings.Contains(line.text, allowSecretAnnotation) || matchAny(allow.paths, line.file) {
+			continue
+		}
+
+		covered := [][]int{}
+		for _, rule := range secretRules {
+			for _, m := range rule.pattern.FindAllStringSubmatchIndex(line.text, -1) {
+				start, end := m[0], m[1]
+				if len(m) > 2 && m[2] >= 0 {
+					start, end = m[2], m[3]
+				}
+				if overlaps(covered, start, end) {
+					continue
+				}
+				secret := line.text[start:end]
+				if rule.entropy > 0 && shannonEntropy(secret) < rule.entropy {
+					continue
+				}
+				covered = append(covered, []int{start, end})
+
+				fingerprint := secretFingerprint(rule.id, secret)
+				key := fmt.Sprintf("%s:%d:%s", line.file, line.number, fingerprint)
+				if seen[key] || allow.allowed(rule.id, secret, fingerprint) {
+					continue
+				}
+				seen[key] = true
+
+				redacted := secret
+				if !rule.plain {
+					redacted = redact(secret)
+				}
+				findings = append(findings, secretFinding{
+					Rule:        rule.id,
+					Description: rule.description,
+					File:        line.file,
+					Line:        line.number,
+					Column:      column(line.text, start),
+					Commit:      line.commit,
+					Secret:      redacted,
+					Fingerprint: fingerprint,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// overlaps reports whether a range overlaps any of the covered ones
+func overlaps(covered [][]int, start, end int) bool {
+	for _, r := range covered {
+		if start < r[1] && r[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSecretFindings renders the findings as text, one per line
+func formatSecretFindings(findings []secretFinding, allowlist string) string {
+	var sb strings.Builder
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "%s:%d:%d: %s (%s): %s", f.File, f.Line, f.Column, f.Description, f.Rule, f.Secret)
+		if f.Commit != "" {
+			fmt.Fprintf(&sb, " in commit %s", shortCommit(f.Commit))
+		}
+		fmt.Fprintf(&sb, " [fingerprint:%s]\n", f.Fingerprint)
+	}
+	if len(findings) > 0 {
+		fmt.Fprintf(&sb, "For a false positive, add %s to the line or its fingerprint to %s\n", allowSecretAnnotation, allowlist)
+	}
+	return sb.String()
+}
+
+// sarifLog is the subset of SARIF 2.1.0 the findings are reported with
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifReport converts the findings to a SARIF log
+func sarifReport(findings []secretFinding) sarifLog {
+	driver := sarifDriver{Name: "husky", InformationURI: "https://github.com/vkunssec/husky", Rules: []sarifRule{}}
+	index := map[string]int{}
+	for i, rule := range secretRules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.id, ShortDescription: sarifMessage{Text: rule.description}})
+		index[rule.id] = i
+	}
+
+	results := []sarifResult{}
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index[f.Rule],
+			Level:     "error",
+			Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", f.Description, f.Secret)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File},
+				Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+			}}},
+			PartialFingerprints: map[string]string{"secretFingerprint/v1": f.Fingerprint},
+		})
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+// SecretsOptions are the options of a secret scan
+type SecretsOptions struct {
+	Range     string // revision range whose commits are scanned, the staged changes when empty
+	Format    string // format of the report, text by default
+	Output    string // file the report is written to, instead of the standard output
+	Allowlist string // allowlist file, .husky/secrets-allowlist by default
+}
+
+// Validate checks the format of the report
+func (o *SecretsOptions) Validate() error {
+	if o.Format != "" && !contains(SecretsFormats, o.Format) {
+		return fmt.Errorf("invalid format '%s', use one of: %s", o.Format, strings.Join(SecretsFormats, ", "))
+	}
+	return nil
+}
+
+// reportSecrets scans the added lines and reports the findings. Text findings go to stderr,
+// JSON and SARIF reports to the output file or stdout
+func reportSecrets(lines []addedLine, opts SecretsOptions, stdout, stderr io.Writer) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	allowlist := opts.Allowlist
+	if allowlist == "" {
+		allowlist = DefaultSecretsAllowlist
+	}
+	allow, err := loadSecretAllowlist(allowlist, opts.Allowlist != "")
+	if err != nil {
+		return fmt.Errorf("failed to load the secrets allowlist: %w", err)
+	}
+
+	findings := scanSecrets(lines, allow)
+
+	var report strings.Builder
+	switch opts.Format {
+	case SecretsJSON:
+		err = writeJSON(&report, findings)
+	case SecretsSARIF:
+		err = writeJSON(&report, sarifReport(findings))
+	default:
+		report.WriteString(formatSecretFindings(findings, allowlist))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Output != "":
+		if err := os.WriteFile(filepath.FromSlash(opts.Output), []byte(report.String()), 0644); err != nil {
+			return err
+		}
+		// the report is for tools, the findings are still shown
+		if opts.Format == SecretsJSON || opts.Format == SecretsSARIF {
+			fmt.Fprint(stderr, formatSecretFindings(findings, allowlist))
+		}
+	case opts.Format == SecretsJSON || opts.Format == SecretsSARIF:
+		fmt.Fprint(stdout, report.String())
+	default:
+		fmt.Fprint(stderr, report.String())
+	}
+
+	switch len(findings) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("1 secret found")
+	default:
+		return fmt.Errorf("%d secrets found", len(findings))
+	}
+}
+
+// scanSecretsCommand scans the staged changes, or the commits of a revision range, for secrets
+func scanSecretsCommand(opts SecretsOptions, stdout, stderr io.Writer) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	var lines []addedLine
+	var err error
+	if opts.Range != "" {
+		lines, err = logAdditions(opts.Range)
+	} else {
+		lines, err = stagedAdditions()
+	}
+	if err != nil {
+		return err
+	}
+	return reportSecrets(lines, opts, stdout, stderr)
+}
+
+// builtinSecrets scans the lines added by the staged changes, or by the pushed commits in
+// pre-push, for secrets
+func builtinSecrets(ctx *builtinContext) error {
+	opts := SecretsOptions{Format: ctx.options["format"], Output: ctx.options["output"], Allowlist: ctx.options["allowlist"]}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	var lines []addedLine
+	var err error
+	if ctx.hook == "pre-push" {
+		lines, err = pushAdditions(ctx.input.PushRefs)
+	} else {
+		lines, err = stagedAdditions()
+	}
+	if err != nil {
+		return err
+	}
+
+	// only the files of the step: those matching its globs, or those of its package
+	scoped := []addedLine{}
+	for _, line := range lines {
+		switch {
+		case len(ctx.files) > 0:
+			if !contains(ctx.files, line.file) {
+				continue
+			}
+		case ctx.dir != "":
+			if !strings.HasPrefix(line.file, filepath.ToSlash(ctx.dir)+"/") {
+				continue
+			}
+		}
+		scoped = append(scoped, line)
+	}
+
+	return reportSecrets(scoped, opts, ctx.stdout, ctx.stderr)
+}
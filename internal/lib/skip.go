@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// CI modes of the config, applied when tools.IsCI reports a CI environment
+const (
+	CIRun     = "run"     // run the hooks as usual, the default
+	CISkip    = "skip"    // skip every hook
+	CIReduced = "reduced" // skip the steps marked with skip_ci
+)
+
+// CIModes are the supported CI modes
+var CIModes = []string{CIRun, CISkip, CIReduced}
+
+// envList returns the comma separated values of an environment variable
+func envList(name string) map[string]bool {
+	values := map[string]bool{}
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values[value] = true
+		}
+	}
+	return values
+}
+
+// hookSkipReason returns why the whole hook must not run, empty if it must
+func hookSkipReason(hook string, config *HuskyConfig) string {
+	switch {
+	case os.Getenv("HUSKY") == "0":
+		return "HUSKY=0"
+	case envList("HUSKY_SKIP_HOOKS")[hook]:
+		return "HUSKY_SKIP_HOOKS"
+	case config.CI == CISkip && tools.IsCI():
+		return "ci: skip"
+	}
+	return ""
+}
+
+// stepSkipReason returns why a step must not run, empty if it must. SKIP matches
+// the name of the step, or its name prefixed by the package directory
+func stepSkipReason(t *task, config *HuskyConfig, skip map[string]bool) string {
+	if t.command.Name != "" && (skip[t.command.Name] || skip[t.name]) {
+		return "skipped with SKIP"
+	}
+	if t.command.SkipCI && config.CI == CIReduced && tools.IsCI() {
+		return "skipped in CI (ci: reduced)"
+	}
+	return ""
+}
+
+// isValidCIMode checks if the CI mode is supported
+func isValidCIMode(mode string) bool {
+	for _, m := range CIModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedSummary is printed instead of the summary when the whole hook is skipped
+func skippedSummary(hook, reason string) string {
+	return fmt.Sprintf("\n husky > %s skipped (%s)\n", hook, reason)
+}
@@ -0,0 +1,131 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSkip(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky", 0755)
+
+	for _, env := range []string{"HUSKY", "HUSKY_SKIP_HOOKS", "SKIP", "CI", "TRAVIS", "CIRCLECI", "GITHUB_ACTIONS", "GITLAB_CI", "JENKINS_URL"} {
+		t.Setenv(env, "")
+	}
+
+	tests := []struct {
+		name        string
+		ci          string
+		env         map[string]string
+		wantSkipped string   // reason the whole hook was skipped
+		wantRun     []string // steps executed
+	}{
+		{name: "Every step runs", wantRun: []string{"lint", "test", "echo unnamed"}},
+		{name: "HUSKY=0", env: map[string]string{"HUSKY": "0"}, wantSkipped: "HUSKY=0"},
+		{name: "HUSKY_SKIP_HOOKS", env: map[string]string{"HUSKY_SKIP_HOOKS": "commit-msg, pre-commit"}, wantSkipped: "HUSKY_SKIP_HOOKS"},
+		{name: "HUSKY_SKIP_HOOKS other hook", env: map[string]string{"HUSKY_SKIP_HOOKS": "pre-push"}, wantRun: []string{"lint", "test", "echo unnamed"}},
+		{name: "SKIP steps", env: map[string]string{"SKIP": "lint,test"}, wantRun: []string{"echo unnamed"}},
+		{name: "CI skip", ci: "skip", env: map[string]string{"CI": "true"}, wantSkipped: "ci: skip"},
+		{name: "CI skip outside CI", ci: "skip", wantRun: []string{"lint", "test", "echo unnamed"}},
+		{name: "CI reduced", ci: "reduced", env: map[string]string{"GITHUB_ACTIONS": "true"}, wantRun: []string{"lint", "echo unnamed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := `hooks:
+  pre-commit:
+    commands:
+      - name: lint
+        run: "true"
+      - name: test
+        run: "true"
+        skip_ci: true
+      - run: echo unnamed
+`
+			if tt.ci != "" {
+				config = "ci: " + tt.ci + "\n" + config
+			}
+			if err := os.WriteFile(".husky/husky.yaml", []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			stdout := new(bytes.Buffer)
+			result, err := Run(RunOptions{Hook: "pre-commit", Stdout: stdout, Stderr: new(bytes.Buffer)})
+			assert.NoError(t, err)
+
+			if tt.wantSkipped != "" {
+				assert.Empty(t, result.Commands)
+				assert.Contains(t, stdout.String(), "pre-commit skipped ("+tt.wantSkipped+")")
+				return
+			}
+
+			ran := []string{}
+			for _, command := range result.Commands {
+				if !command.Skipped {
+					ran = append(ran, command.Name)
+				}
+			}
+			assert.Equal(t, tt.wantRun, ran)
+		})
+	}
+}
+
+func TestShimSkip(t *testing.T) {
+	// a fake husky failing, so the shim only succeeds when it exits before calling it
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "husky"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+
+	shim := filepath.Join(t.TempDir(), "pre-push")
+	os.WriteFile(shim, []byte(renderHookScript("pre-push", &HookConfig{}, NewDefaultConfig())), 0755)
+
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{"HUSKY=1", true},
+		{"HUSKY=0", false},
+		{"HUSKY_SKIP_HOOKS=commit-msg,pre-push", false},
+		{"HUSKY_SKIP_HOOKS=pre-push-extra", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cmd := exec.Command(shim)
+			cmd.Env = []string{"PATH=" + bin + ":/usr/bin:/bin", tt.env}
+			err := cmd.Run()
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestShimMissingHusky(t *testing.T) {
+	shim := filepath.Join(t.TempDir(), "pre-commit")
+	os.WriteFile(shim, []byte(renderHookScript("pre-commit", &HookConfig{}, NewDefaultConfig())), 0755)
+
+	// an empty directory as PATH, only the shell builtins are available
+	cmd := exec.Command("/bin/sh", shim)
+	cmd.Env = []string{"PATH=" + t.TempDir()}
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 127, exitErr.ExitCode())
+	}
+	assert.Contains(t, string(out), "husky: command not found, cannot run the pre-commit hook")
+
+	cmd = exec.Command("/bin/sh", shim)
+	cmd.Env = []string{"PATH=" + t.TempDir(), "HUSKY=0"}
+	assert.NoError(t, cmd.Run())
+}
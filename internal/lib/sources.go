@@ -0,0 +1,386 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// sourceBuiltin is the source of the templates shipped with husky
+const sourceBuiltin = "builtin"
+
+// exported functions
+var (
+	PullTemplates   = pullTemplates
+	UpdateTemplates = updateTemplates
+)
+
+// Validate checks that the source has a URL and a name usable as a template prefix
+func (s TemplateSource) Validate() error {
+	if strings.TrimSpace(s.URL) == "" {
+		return errors.New("template source without url")
+	}
+	name := s.name()
+	if name == "" || name == "." || strings.ContainsAny(name, `/\ `) {
+		return fmt.Errorf("invalid template source name '%s'", name)
+	}
+	if filepath.IsAbs(s.Path) || strings.HasPrefix(filepath.Clean(s.Path), "..") {
+		return fmt.Errorf("template source path '%s' must be inside the repository", s.Path)
+	}
+	return nil
+}
+
+// name returns the name of the source, or the name of its repository
+func (s TemplateSource) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	url := strings.TrimRight(strings.ReplaceAll(s.URL, `\`, "/"), "/")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(url, ".git")
+}
+
+// ref returns the ref the source is pinned to
+func (s TemplateSource) ref() string {
+	if s.Ref != "" {
+		return s.Ref
+	}
+	return "HEAD"
+}
+
+// label describes the source in listings
+func (s TemplateSource) label() string {
+	return s.URL + "@" + s.ref()
+}
+
+// userTemplatesDir returns the directory of the templates of the user, ~/.config/husky/templates on Linux
+func userTemplatesDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "husky", "templates")
+}
+
+// projectTemplatesDir returns the templates directory of the config, relative to .husky
+func projectTemplatesDir(config *HuskyConfig) string {
+	if config.HooksTemplatesDir == "" || filepath.IsAbs(config.HooksTemplatesDir) {
+		return config.HooksTemplatesDir
+	}
+	return filepath.Join(tools.GetHuskyDir(true), config.HooksTemplatesDir)
+}
+
+// sourceCacheDir returns where a source is checked out, one directory per repository and ref
+func sourceCacheDir(source TemplateSource) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(source.URL + "\x00" + source.ref()))
+	return filepath.Join(dir, "husky", "templates", hex.EncodeToString(sum[:8])), nil
+}
+
+// loadTemplateDir loads the templates of a directory: YAML files are config templates
+// and files named after a hook are scripts for it. A missing directory has no templates.
+func loadTemplateDir(dir, prefix, source string) (map[string]*HookTemplate, error) {
+	templates := map[string]*HookTemplate{}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := path.Ext(name)
+		isConfig := ext == ".yaml" || ext == ".yml"
+		if entry.IsDir() || (!isConfig && !tools.IsValidHook(name)) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		var tpl *HookTemplate
+		if isConfig {
+			tpl = configTemplate(prefix+strings.TrimSuffix(name, ext), string(content))
+		} else {
+			tpl = scriptTemplate(prefix+name, name, string(content))
+		}
+		tpl.Source = source
+		templates[tpl.Name] = tpl
+	}
+
+	return templates, nil
+}
+
+// loadTemplateSources adds the templates of the user, of the git sources and of the
+// project to the builtin ones, the project ones taking precedence over the user ones.
+// Git sources are fetched the first time and prefix their templates with their name.
+func loadTemplateSources(templates map[string]*HookTemplate, config *HuskyConfig) {
+	merge := func(dir, prefix, source string) {
+		if dir == "" {
+			return
+		}
+		found, err := loadTemplateDir(dir, prefix, source)
+		if err != nil {
+			tools.LogError("failed to load templates from %s: %v", source, err)
+			return
+		}
+		for name, tpl := range found {
+			templates[name] = tpl
+		}
+	}
+
+	merge(userTemplatesDir(), "", userTemplatesDir())
+
+	for _, source := range config.TemplateSources {
+		dir, err := sourceCacheDir(source)
+		if err != nil {
+			tools.LogError("failed to load templates from %s: %v", source.label(), err)
+			continue
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			if _, err := fetchTemplateSource(source); err != nil {
+				tools.LogError("failed to fetch templates from %s: %v", source.label(), err)
+				continue
+			}
+		}
+		merge(filepath.Join(dir, source.Path), source.name()+"/", source.label())
+	}
+
+	merge(projectTemplatesDir(config), "", projectTemplatesDir(config))
+}
+
+// fetchTemplateSource checks out the ref of a source in the cache, replacing the
+// previous checkout only once the fetch succeeded, and returns the commit
+func fetchTemplateSource(source TemplateSource) (string, error) {
+	dir, err := sourceCacheDir(source)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	// git would resolve a relative local repository from the checkout
+	url := source.URL
+	if local, ok := localSourcePath(url); ok {
+		url = local
+	}
+
+	steps := [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", url, source.ref()},
+		{"-c", "advice.detachedHead=false", "checkout", "-q", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if _, err := tools.Git(append([]string{"-C", tmp}, args...)...); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := sourceCommit(tmp)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// isRemoteURL reports whether a source URL names a remote repository: a URL with a
+// scheme or the scp-like host:path syntax
+func isRemoteURL(url string) bool {
+	if strings.Contains(url, "://") {
+		return true
+	}
+	// a single letter before the colon is a Windows drive
+	i := strings.Index(url, ":")
+	return i > 1 && !strings.ContainsAny(url[:i], `/\`)
+}
+
+// localSourcePath returns the absolute path of a source URL naming a local repository,
+// a relative one being relative to the root of the working tree
+func localSourcePath(url string) (string, bool) {
+	if isRemoteURL(url) {
+		return "", false
+	}
+	dir := filepath.FromSlash(url)
+	if !filepath.IsAbs(dir) {
+		repo, err := tools.FindRepository()
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.Join(repo.WorkTree, dir)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", false
+	}
+	return dir, true
+}
+
+// rootRelativeURL rewrites a relative local source URL, as typed from the current
+// directory, to be relative to the root of the working tree like the config expects
+func rootRelativeURL(url string) string {
+	if isRemoteURL(url) || filepath.IsAbs(url) {
+		return url
+	}
+	if _, err := os.Stat(url); err != nil {
+		return url
+	}
+	repo, err := tools.FindRepository()
+	if err != nil {
+		return url
+	}
+	abs, err := filepath.Abs(url)
+	if err != nil {
+		return url
+	}
+	rel, err := filepath.Rel(repo.WorkTree, abs)
+	if err != nil {
+		return url
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sourceCommit returns the commit checked out in a cached source, empty if it was never fetched
+func sourceCommit(dir string) (string, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", nil
+	}
+	out, err := tools.Git("-C", dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// shortCommit abbreviates a commit for messages
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	if commit == "" {
+		return "none"
+	}
+	return commit
+}
+
+// pullTemplates fetches the templates of a git source and saves the source in the
+// config, replacing the source with the same name
+func pullTemplates(source TemplateSource) error {
+	if !tools.GitExists() {
+		return errors.New("git not initialized")
+	}
+	if !tools.HuskyExists() {
+		return errors.New(".husky not initialized")
+	}
+	if err := source.Validate(); err != nil {
+		return err
+	}
+	source.URL = rootRelativeURL(source.URL)
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	commit, err := fetchTemplateSource(source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch templates: %w", err)
+	}
+
+	dir, _ := sourceCacheDir(source)
+	found, err := loadTemplateDir(filepath.Join(dir, source.Path), source.name()+"/", source.label())
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("no templates found in %s", source.label())
+	}
+
+	replaced := false
+	for i, existing := range config.TemplateSources {
+		if existing.name() == source.name() {
+			config.TemplateSources[i] = source
+			replaced = true
+		}
+	}
+	if !replaced {
+		config.TemplateSources = append(config.TemplateSources, source)
+	}
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	tools.LogInfo("%s: %d templates at %s, use them with --template %s/<name>", source.name(), len(found), shortCommit(commit), source.name())
+	return nil
+}
+
+// updateTemplates fetches again the git sources of the config, or the given ones,
+// picking up the new commits of the refs they are pinned to
+func updateTemplates(names []string) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	for _, source := range config.TemplateSources {
+		if len(wanted) > 0 && !wanted[source.name()] {
+			continue
+		}
+		delete(wanted, source.name())
+
+		dir, err := sourceCacheDir(source)
+		if err != nil {
+			return err
+		}
+		previous, _ := sourceCommit(dir)
+		commit, err := fetchTemplateSource(source)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", source.name(), err)
+		}
+
+		if previous == commit {
+			tools.LogInfo("%s: up to date at %s", source.name(), shortCommit(commit))
+		} else {
+			tools.LogInfo("%s: %s -> %s", source.name(), shortCommit(previous), shortCommit(commit))
+		}
+	}
+
+	for name := range wanted {
+		return fmt.Errorf("template source '%s' not found", name)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const platformTemplate = `description: Platform checks
+hooks:
+  pre-commit:
+    commands:
+      - name: platform
+        run: echo v1
+`
+
+func TestTemplateSourceName(t *testing.T) {
+	tests := []struct {
+		source TemplateSource
+		want   string
+	}{
+		{TemplateSource{URL: "https://github.com/acme/hooks.git"}, "hooks"},
+		{TemplateSource{URL: "git@github.com:acme/hooks.git"}, "hooks"},
+		{TemplateSource{URL: "/srv/git/hooks/"}, "hooks"},
+		{TemplateSource{URL: "https://github.com/acme/hooks.git", Name: "acme"}, "acme"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.source.name(), tt.source.URL)
+		assert.NoError(t, tt.source.Validate())
+	}
+
+	assert.Error(t, TemplateSource{}.Validate())
+	assert.Error(t, TemplateSource{URL: "x", Name: "a/b"}.Validate())
+	assert.Error(t, TemplateSource{URL: "x", Path: "../up"}.Validate())
+}
+
+func TestLoadTemplateSources(t *testing.T) {
+	initGitRepo(t)
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+
+	write := func(file, content string) {
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(userDir, "husky", "templates", "mine.yaml"), platformTemplate)
+	write(filepath.Join(userDir, "husky", "templates", "go.yml"), platformTemplate)
+	write(filepath.Join(userDir, "husky", "templates", "commit-msg"), "#!/bin/sh\nexit 0\n")
+	write(filepath.Join(userDir, "husky", "templates", "README.md"), "ignored")
+	write(filepath.Join(".husky", "templates", "go.yaml"), "description: Project Go\nhooks:\n  pre-push:\n    commands:\n      - run: make test\n")
+
+	templates := LoadTemplates()
+
+	assert.Equal(t, "Platform checks", templates["mine"].Description)
+	assert.Equal(t, "commit-msg", templates["commit-msg"].Hook)
+	assert.NotContains(t, templates, "README")
+	// the project templates take precedence over the user and builtin ones
+	assert.Equal(t, "Project Go", templates["go"].Description)
+	assert.True(t, strings.HasSuffix(templates["go"].Source, filepath.Join(".husky", "templates")), templates["go"].Source)
+	assert.Equal(t, sourceBuiltin, templates["security"].Source)
+}
+
+func TestPullAndUpdateTemplates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// the repository published by the platform team
+	upstream := t.TempDir()
+	gitCmd(t, "-C", upstream, "init", "-q")
+	gitCmd(t, "-C", upstream, "config", "user.email", "husky@example.com")
+	gitCmd(t, "-C", upstream, "config", "user.name", "Husky")
+	gitCmd(t, "-C", upstream, "config", "commit.gpgsign", "false")
+	publish := func(content string) {
+		os.MkdirAll(filepath.Join(upstream, "templates"), 0755)
+		os.WriteFile(filepath.Join(upstream, "templates", "service.yaml"), []byte(content), 0644)
+		gitCmd(t, "-C", upstream, "add", "-A")
+		gitCmd(t, "-C", upstream, "commit", "-q", "-m", "templates")
+	}
+	publish(platformTemplate)
+	gitCmd(t, "-C", upstream, "tag", "v1")
+	branch := strings.TrimSpace(gitCmd(t, "-C", upstream, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	setupHooks(t)
+
+	assert.NoError(t, PullTemplates(TemplateSource{Name: "platform", URL: upstream, Ref: "v1", Path: "templates"}))
+	assert.Error(t, PullTemplates(TemplateSource{Name: "empty", URL: upstream, Ref: "v1", Path: "missing"}))
+	assert.Error(t, PullTemplates(TemplateSource{URL: filepath.Join(upstream, "missing")}))
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []TemplateSource{{Name: "platform", URL: upstream, Ref: "v1", Path: "templates"}}, config.TemplateSources)
+
+	templates := LoadTemplates()
+	if assert.Contains(t, templates, "platform/service") {
+		assert.Contains(t, templates["platform/service"].Content, "echo v1")
+		assert.Equal(t, upstream+"@v1", templates["platform/service"].Source)
+	}
+	assert.NoError(t, AddTemplate("platform/service", "", false))
+
+	// a source pinned to a tag stays on it, a source following a branch moves with it
+	publish("description: Platform checks\nhooks:\n  pre-commit:\n    commands:\n      - name: platform\n        run: echo v2\n")
+	assert.NoError(t, UpdateTemplates(nil))
+	assert.Contains(t, LoadTemplates()["platform/service"].Content, "echo v1")
+
+	assert.NoError(t, PullTemplates(TemplateSource{Name: "platform", URL: upstream, Ref: branch, Path: "templates"}))
+	assert.Contains(t, LoadTemplates()["platform/service"].Content, "echo v2")
+	config, _ = LoadConfig()
+	assert.Len(t, config.TemplateSources, 1)
+
+	assert.NoError(t, UpdateTemplates([]string{"platform"}))
+	assert.Error(t, UpdateTemplates([]string{"unknown"}))
+
+	// the cache is enough to load the templates without the repository
+	os.RemoveAll(upstream)
+	assert.Contains(t, LoadTemplates(), "platform/service")
+}
+
+func TestPullRelativeTemplateSource(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	setupHooks(t)
+	root, _ := os.Getwd()
+
+	upstream := filepath.Join(root, "platform")
+	os.MkdirAll(filepath.Join(upstream, "templates"), 0755)
+	os.WriteFile(filepath.Join(upstream, "templates", "service.yaml"), []byte(platformTemplate), 0644)
+	gitCmd(t, "-C", upstream, "init", "-q")
+	gitCmd(t, "-C", upstream, "config", "user.email", "husky@example.com")
+	gitCmd(t, "-C", upstream, "config", "user.name", "Husky")
+	gitCmd(t, "-C", upstream, "config", "commit.gpgsign", "false")
+	gitCmd(t, "-C", upstream, "add", "-A")
+	gitCmd(t, "-C", upstream, "commit", "-q", "-m", "templates")
+
+	// pulled from a subdirectory with a path relative to it
+	os.MkdirAll(filepath.Join("services", "api"), 0755)
+	os.Chdir(filepath.Join(root, "services"))
+	assert.NoError(t, PullTemplates(TemplateSource{URL: filepath.Join("..", "platform"), Path: "templates"}))
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	if assert.Len(t, config.TemplateSources, 1) {
+		assert.Equal(t, "platform", config.TemplateSources[0].URL)
+	}
+
+	// fetched again from another directory
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	os.Chdir(filepath.Join(root, "services", "api"))
+	assert.Contains(t, LoadTemplates(), "platform/service")
+
+	assert.True(t, isRemoteURL("git@github.com:acme/hooks.git"))
+	assert.True(t, isRemoteURL("ssh://git@example.com/hooks.git"))
+	assert.False(t, isRemoteURL(`C:\repos\hooks`))
+	assert.False(t, isRemoteURL("../hooks"))
+}
@@ -0,0 +1,178 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exported functions
+var (
+	Reorder = reorder
+)
+
+// addStep adds a named step after the existing ones, or replaces the step with the same name
+func addStep(hook *HookConfig, command *HookCommand, force bool) error {
+	for i, step := range hook.Commands {
+		if step.Name != command.Name {
+			continue
+		}
+		if !force {
+			if err := confirmOverwrite(fmt.Sprintf("Step '%s'", command.Name), "use --force to replace it"); err != nil {
+				return err
+			}
+		}
+		// keep the filters and dependencies of the step, only the command changes
+		step.Run = command.Run
+		hook.Commands[i] = step
+		return nil
+	}
+
+	hook.Commands = append(hook.Commands, command)
+	return nil
+}
+
+// findStep returns the index of the step referred by its name or its position, starting at 1
+func findStep(hook *HookConfig, ref string) (int, error) {
+	for i, step := range hook.Commands {
+		if step.Name == ref {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(hook.Commands) {
+		return n - 1, nil
+	}
+	return -1, fmt.Errorf("step '%s' not found", ref)
+}
+
+// removeStep removes a step, refusing when other steps need it
+func removeStep(hook *HookConfig, ref string) error {
+	i, err := findStep(hook, ref)
+	if err != nil {
+		return err
+	}
+
+	removed := hook.Commands[i]
+	if removed.Name != "" {
+		for _, step := range hook.Commands {
+			for _, need := range step.Needs {
+				if need == removed.Name {
+					return fmt.Errorf("step '%s' is needed by '%s'", removed.Name, step.DisplayName())
+				}
+			}
+		}
+	}
+
+	hook.Commands = append(hook.Commands[:i], hook.Commands[i+1:]...)
+	return nil
+}
+
+// reorder moves the given steps of a hook first, in the given order, keeping the
+// relative order of the other steps after them
+func reorder(hook string, steps []string) error {
+	if err := checkHookCommand(hook); err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return errors.New("no steps given")
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	declaration, ok := config.Hooks[hook]
+	if !ok || declaration == nil || len(declaration.Commands) == 0 {
+		return fmt.Errorf("hook '%s' has no steps", hook)
+	}
+
+	moved := map[int]bool{}
+	ordered := []*HookCommand{}
+	for _, ref := range steps {
+		i, err := findStep(declaration, ref)
+		if err != nil {
+			return err
+		}
+		if moved[i] {
+			return fmt.Errorf("step '%s' given twice", ref)
+		}
+		moved[i] = true
+		ordered = append(ordered, declaration.Commands[i])
+	}
+	for i, step := range declaration.Commands {
+		if !moved[i] {
+			ordered = append(ordered, step)
+		}
+	}
+	declaration.Commands = ordered
+
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// listSteps prints the steps of the hooks declared in the config, or of a single hook
+func listSteps(w io.Writer, hook string, asJSON bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	hooks := map[string]*HookConfig{}
+	for name, declaration := range config.Hooks {
+		if hook == "" || name == hook {
+			hooks[name] = declaration
+		}
+	}
+	if hook != "" && len(hooks) == 0 {
+		return fmt.Errorf("hook '%s' is not defined in %s", hook, config.Path())
+	}
+
+	if asJSON {
+		return writeJSON(w, hooks)
+	}
+
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	if len(names) == 0 {
+		sb.WriteString(" No hooks defined in the config.\n")
+	}
+	for _, name := range names {
+		declaration := hooks[name]
+		title := name
+		if declaration != nil && declaration.Disabled {
+			title += " (disabled)"
+		}
+		sb.WriteString(fmt.Sprintf(" %s%s%s\n", green, title, nc))
+		if declaration == nil {
+			continue
+		}
+		for i, step := range declaration.Commands {
+			line := fmt.Sprintf("   %d. ", i+1)
+			if step.Name != "" {
+				line += step.Name + ": "
+			}
+			if step.Builtin != "" {
+				line += "builtin " + step.Builtin
+			} else {
+				line += strings.TrimSpace(strings.SplitN(step.Run, "\n", 2)[0])
+			}
+			if len(step.Needs) > 0 {
+				line += fmt.Sprintf(" (needs %s)", strings.Join(step.Needs, ", "))
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	_, err = fmt.Fprint(w, sb.String())
+	return err
+}
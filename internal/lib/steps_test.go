@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSteps(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(originalWd)
+
+	os.Mkdir(".git", 0755)
+	os.MkdirAll(".husky/hooks", 0755)
+
+	stepNames := func() []string {
+		config, err := LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, step := range config.Hooks["pre-commit"].Commands {
+			names = append(names, step.Name+"="+step.Run)
+		}
+		return names
+	}
+
+	// named steps are added after the existing ones
+	assert.NoError(t, Add("pre-commit", "golangci-lint run", AddOptions{Name: "lint"}))
+	assert.NoError(t, Add("pre-commit", "go vet ./...", AddOptions{Name: "vet"}))
+	assert.NoError(t, Add("pre-commit", "go test ./...", AddOptions{Name: "test"}))
+	assert.Equal(t, []string{"lint=golangci-lint run", "vet=go vet ./...", "test=go test ./..."}, stepNames())
+
+	// replacing a step needs --force without a terminal
+	stdin := os.Stdin
+	r, w, _ := os.Pipe()
+	defer w.Close()
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	assert.ErrorContains(t, Add("pre-commit", "go test -race ./...", AddOptions{Name: "test"}), "--force")
+	assert.NoError(t, Add("pre-commit", "go test -race ./...", AddOptions{Name: "test", Force: true}))
+	assert.Equal(t, "test=go test -race ./...", stepNames()[2])
+
+	t.Run("Reorder", func(t *testing.T) {
+		assert.NoError(t, Reorder("pre-commit", []string{"test", "1"}))
+		assert.Equal(t, []string{"test=go test -race ./...", "lint=golangci-lint run", "vet=go vet ./..."}, stepNames())
+
+		assert.ErrorContains(t, Reorder("pre-commit", []string{"build"}), "step 'build' not found")
+		assert.ErrorContains(t, Reorder("pre-commit", []string{"lint", "2"}), "given twice")
+		assert.Error(t, Reorder("pre-push", []string{"lint"}))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		config, _ := LoadConfig()
+		config.Hooks["pre-commit"].Commands[0].Needs = []string{"vet"}
+		assert.NoError(t, SaveConfig(config))
+
+		stdout := new(bytes.Buffer)
+		assert.NoError(t, List(ListOptions{Hook: "pre-commit", Stdout: stdout}))
+		assert.Contains(t, stdout.String(), "   1. test: go test -race ./... (needs vet)\n   2. lint: golangci-lint run\n   3. vet: go vet ./...\n")
+
+		assert.Error(t, List(ListOptions{Hook: "pre-push", Stdout: stdout}))
+	})
+
+	t.Run("Remove step", func(t *testing.T) {
+		assert.ErrorContains(t, Remove("pre-commit", RemoveOptions{Step: "vet"}), "needed by 'test'")
+		assert.NoError(t, Remove("pre-commit", RemoveOptions{Step: "test"}))
+		assert.NoError(t, Remove("pre-commit", RemoveOptions{Step: "2"}))
+		assert.Equal(t, []string{"lint=golangci-lint run"}, stepNames())
+		assert.FileExists(t, ".husky/hooks/pre-commit")
+
+		// the hook goes away with its last step
+		assert.NoError(t, Remove("pre-commit", RemoveOptions{Step: "lint"}))
+		config, _ := LoadConfig()
+		assert.NotContains(t, config.Hooks, "pre-commit")
+		assert.NoFileExists(t, ".husky/hooks/pre-commit")
+	})
+}
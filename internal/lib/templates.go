@@ -0,0 +1,321 @@
+package lib
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vkunssec/husky/examples"
+	"github.com/vkunssec/husky/internal/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinTemplates are the config templates shipped with husky, one YAML file per template
+//
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+// examplePrefix is the prefix of the templates made from the example scripts
+const examplePrefix = "examples/"
+
+// exampleDescriptions describes the example scripts, which carry no description themselves
+var exampleDescriptions = map[string]string{
+	"commit-msg":  "Example script checking the Conventional Commits pattern",
+	"pre-commit":  "Example script running go mod tidy, gofmt and go vet",
+	"post-commit": "Example script committing the pending docs changes in a separate commit",
+}
+
+// exported functions
+var (
+	AddTemplate   = addTemplate
+	ListTemplates = listTemplates
+	ShowTemplate  = showTemplate
+)
+
+// templateFile is the layout of a config template: a description and the hooks to declare
+type templateFile struct {
+	Description string                 `yaml:"description"`
+	Hooks       map[string]*HookConfig `yaml:"hooks"`
+}
+
+// IsScript reports whether the template is a script for a single hook rather than a config fragment
+func (t *HookTemplate) IsScript() bool {
+	return t.Hook != ""
+}
+
+// LoadTemplates returns the available templates by name: the ones shipped with husky,
+// then the ones of the user, of the git sources and of the project
+func LoadTemplates() map[string]*HookTemplate {
+	templates := builtinTemplateSet()
+
+	config, err := LoadConfig()
+	if err != nil {
+		tools.LogError("failed to load config, only the builtin templates are available: %v", err)
+		return templates
+	}
+	loadTemplateSources(templates, config)
+
+	return templates
+}
+
+// builtinTemplateSet returns the templates shipped with husky: the config templates and the example scripts
+func builtinTemplateSet() map[string]*HookTemplate {
+	templates := map[string]*HookTemplate{}
+
+	files, _ := fs.Glob(builtinTemplates, "templates/*.yaml")
+	for _, file := range files {
+		content, err := builtinTemplates.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		tpl := configTemplate(strings.TrimSuffix(path.Base(file), ".yaml"), string(content))
+		tpl.Source = sourceBuiltin
+		templates[tpl.Name] = tpl
+	}
+
+	entries, _ := fs.ReadDir(examples.Hooks, ".")
+	for _, entry := range entries {
+		content, err := examples.Hooks.ReadFile(entry.Name())
+		if err != nil {
+			continue
+		}
+		tpl := scriptTemplate(examplePrefix+entry.Name(), entry.Name(), string(content))
+		tpl.Description = exampleDescriptions[entry.Name()]
+		tpl.Source = sourceBuiltin
+		templates[tpl.Name] = tpl
+	}
+
+	return templates
+}
+
+// configTemplate returns a template declaring hooks in the config
+func configTemplate(name, content string) *HookTemplate {
+	var file templateFile
+	_ = yaml.Unmarshal([]byte(content), &file) // reported by Validate before the template is used
+	return &HookTemplate{Name: name, Description: file.Description, Content: content, Validate: validateConfigTemplate}
+}
+
+// scriptTemplate returns a template installing a script run by a hook
+func scriptTemplate(name, hook, content string) *HookTemplate {
+	return &HookTemplate{Name: name, Hook: hook, Content: content, Validate: validateScript}
+}
+
+// parseConfigTemplate decodes a config template, rejecting unknown keys
+func parseConfigTemplate(content string) (*templateFile, error) {
+	var file templateFile
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &file, nil
+}
+
+// validateConfigTemplate checks that a config template declares valid hooks
+func validateConfigTemplate(content string) error {
+	file, err := parseConfigTemplate(content)
+	if err != nil {
+		return err
+	}
+	if len(file.Hooks) == 0 {
+		return errors.New("template declares no hooks")
+	}
+	for name, hook := range file.Hooks {
+		if !tools.IsValidHook(name) {
+			return fmt.Errorf("invalid hook '%s'", name)
+		}
+		if hook == nil || len(hook.Commands) == 0 {
+			return fmt.Errorf("hook '%s' has no commands", name)
+		}
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("hook '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateScript checks that a script template has a shebang and, when sh is
+// available, a valid syntax
+func validateScript(content string) error {
+	if !strings.HasPrefix(content, "#!") {
+		return errors.New("script has no shebang")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-n")
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("invalid script: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// lookupTemplate returns a template by name, listing the available ones when it does not exist
+func lookupTemplate(templates map[string]*HookTemplate, name string) (*HookTemplate, error) {
+	if tpl, ok := templates[name]; ok {
+		return tpl, nil
+	}
+	return nil, fmt.Errorf("template '%s' not found, available: %s", name, strings.Join(templateNames(templates), ", "))
+}
+
+// templateNames returns the names of the templates, sorted
+func templateNames(templates map[string]*HookTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyTemplate validates a template and declares its hooks in the config; a script
+// template is written to .husky/scripts and run by a step of its hook, or of the given one
+func applyTemplate(config *HuskyConfig, tpl *HookTemplate, hook string, force bool) error {
+	if tpl.Validate != nil {
+		if err := tpl.Validate(tpl.Content); err != nil {
+			return fmt.Errorf("template '%s': %w", tpl.Name, err)
+		}
+	}
+
+	if !tpl.IsScript() {
+		if hook != "" {
+			return fmt.Errorf("template '%s' declares its own hooks, the hook cannot be given", tpl.Name)
+		}
+		file, err := parseConfigTemplate(tpl.Content)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedHookNames(file.Hooks) {
+			if err := mergeHook(config.Hook(name), file.Hooks[name], force); err != nil {
+				return fmt.Errorf("hook '%s': %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	if hook == "" {
+		hook = tpl.Hook
+	}
+	if !tools.IsValidHook(hook) {
+		return fmt.Errorf("invalid hook '%s'", hook)
+	}
+
+	name := path.Base(tpl.Name)
+	dir := filepath.Join(tools.GetHuskyDir(true), "scripts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := createHook(dir, name, tpl.Content, config); err != nil {
+		return fmt.Errorf("failed to write script: %w", err)
+	}
+
+	step := &HookCommand{Name: name, Run: fmt.Sprintf(`.husky/scripts/%s "$@"`, name)}
+	return mergeHook(config.Hook(hook), &HookConfig{Commands: []*HookCommand{step}}, force)
+}
+
+// mergeHook adds the steps of a template to a hook, replacing the steps with the same name
+func mergeHook(hook, from *HookConfig, force bool) error {
+	hook.Parallel = hook.Parallel || from.Parallel
+	for _, command := range from.Commands {
+		i, err := findStep(hook, command.Name)
+		if command.Name == "" || err != nil {
+			hook.Commands = append(hook.Commands, command)
+			continue
+		}
+		if !force && !sameCommand(hook.Commands[i], command) {
+			if err := confirmOverwrite(fmt.Sprintf("Step '%s'", command.Name), "use --force to replace it"); err != nil {
+				return err
+			}
+		}
+		hook.Commands[i] = command
+	}
+	return hook.Validate()
+}
+
+// sameCommand reports whether two steps are identical
+func sameCommand(a, b *HookCommand) bool {
+	x, _ := yaml.Marshal(a)
+	y, _ := yaml.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+// sortedHookNames returns the names of the hooks, sorted
+func sortedHookNames(hooks map[string]*HookConfig) []string {
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addTemplate declares the hooks of a template in the config and writes their scripts
+func addTemplate(name, hook string, force bool) error {
+	if !tools.GitExists() {
+		return errors.New("git not initialized")
+	}
+	if !tools.HuskyExists() {
+		return errors.New(".husky not initialized")
+	}
+
+	tpl, err := lookupTemplate(LoadTemplates(), name)
+	if err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	if err := applyTemplate(config, tpl, hook, force); err != nil {
+		return err
+	}
+	if err := SaveConfig(config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return writeHookScripts(tools.GetHuskyHooksDir(true), config)
+}
+
+// listTemplates prints the available templates with the hook they target and their description
+func listTemplates(w io.Writer) error {
+	templates := LoadTemplates()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tHOOKS\tSOURCE\tDESCRIPTION")
+	for _, name := range templateNames(templates) {
+		tpl := templates[name]
+		hooks := tpl.Hook
+		if !tpl.IsScript() {
+			if file, err := parseConfigTemplate(tpl.Content); err == nil {
+				hooks = strings.Join(sortedHookNames(file.Hooks), ",")
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, hooks, tpl.Source, tpl.Description)
+	}
+	return tw.Flush()
+}
+
+// showTemplate prints the content of a template
+func showTemplate(w io.Writer, name string) error {
+	tpl, err := lookupTemplate(LoadTemplates(), name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, tpl.Content)
+	return err
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	templates := LoadTemplates()
+
+	for _, name := range []string{"go", "conventional", "security", "examples/commit-msg", "examples/pre-commit", "examples/post-commit"} {
+		tpl, ok := templates[name]
+		if !assert.True(t, ok, "template %s missing", name) {
+			continue
+		}
+		assert.Equal(t, name, tpl.Name)
+		assert.NotEmpty(t, tpl.Description, "template %s has no description", name)
+		assert.NoError(t, tpl.Validate(tpl.Content), "template %s is invalid", name)
+	}
+
+	assert.True(t, templates["examples/commit-msg"].IsScript())
+	assert.Equal(t, "commit-msg", templates["examples/commit-msg"].Hook)
+	assert.False(t, templates["go"].IsScript())
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		content  string
+		wantErr  bool
+	}{
+		{"Valid config", validateConfigTemplate, "hooks:\n  pre-commit:\n    commands:\n      - run: go vet ./...\n", false},
+		{"Config without hooks", validateConfigTemplate, "description: empty\n", true},
+		{"Config with unknown key", validateConfigTemplate, "hook:\n  pre-commit: {}\n", true},
+		{"Config with invalid hook", validateConfigTemplate, "hooks:\n  pre-merge:\n    commands:\n      - run: exit 0\n", true},
+		{"Config with unknown need", validateConfigTemplate, "hooks:\n  pre-commit:\n    commands:\n      - name: a\n        run: exit 0\n        needs: [b]\n", true},
+		{"Valid script", validateScript, "#!/bin/sh\nexit 0\n", false},
+		{"Script without shebang", validateScript, "exit 0\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.content)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("Script with syntax error", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("sh not available")
+		}
+		assert.Error(t, validateScript("#!/bin/sh\nif true; then\n"))
+	})
+}
+
+func TestInitWithTemplate(t *testing.T) {
+	initGitRepo(t)
+
+	err := Init(InitOptions{Config: NewDefaultConfig(), Templates: LoadTemplates(), Template: "go", Quiet: true})
+	assert.NoError(t, err)
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Len(t, config.Hooks["pre-commit"].Commands, 3)
+	assert.Equal(t, "go:fmt", config.Hooks["pre-commit"].Commands[0].Builtin)
+	assert.Equal(t, "go:test", config.Hooks["pre-push"].Commands[1].Builtin)
+	assert.FileExists(t, ".husky/hooks/pre-push")
+}
+
+func TestInitWithUnknownTemplate(t *testing.T) {
+	initGitRepo(t)
+
+	err := Init(InitOptions{Config: NewDefaultConfig(), Templates: LoadTemplates(), Template: "rust", Quiet: true})
+	assert.ErrorContains(t, err, "template 'rust' not found")
+	assert.NoDirExists(t, ".husky")
+}
+
+func TestAddTemplate(t *testing.T) {
+	setupHooks(t)
+
+	t.Run("config template", func(t *testing.T) {
+		assert.NoError(t, AddTemplate("conventional", "", false))
+		config, _ := LoadConfig()
+		assert.Equal(t, "conventional", config.Hooks["commit-msg"].Commands[0].Name)
+		assert.FileExists(t, ".husky/hooks/commit-msg")
+
+		// applying it again changes nothing and asks nothing
+		assert.NoError(t, AddTemplate("conventional", "", false))
+		config, _ = LoadConfig()
+		assert.Len(t, config.Hooks["commit-msg"].Commands, 1)
+
+		assert.Error(t, AddTemplate("conventional", "pre-commit", false))
+	})
+
+	t.Run("script template", func(t *testing.T) {
+		assert.NoError(t, AddTemplate("examples/post-commit", "", false))
+		info, err := os.Stat(filepath.Join(".husky", "scripts", "post-commit"))
+		if assert.NoError(t, err) {
+			assert.NotZero(t, info.Mode()&0100)
+		}
+		config, _ := LoadConfig()
+		assert.Equal(t, `.husky/scripts/post-commit "$@"`, config.Hooks["post-commit"].Commands[0].Run)
+	})
+
+	t.Run("conflicting step without force", func(t *testing.T) {
+		config, _ := LoadConfig()
+		config.Hooks["commit-msg"].Commands[0] = &HookCommand{Name: "conventional", Run: "exit 0"}
+		assert.NoError(t, SaveConfig(config))
+
+		r, w, _ := os.Pipe()
+		defer r.Close()
+		defer w.Close()
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+
+		assert.ErrorContains(t, AddTemplate("conventional", "", false), "use --force")
+		assert.NoError(t, AddTemplate("conventional", "", true))
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		assert.ErrorContains(t, AddTemplate("rust", "", false), "available: conventional")
+	})
+}
+
+func TestListAndShowTemplates(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, ListTemplates(&out))
+	assert.Contains(t, out.String(), "security")
+	assert.Contains(t, out.String(), "pre-commit,pre-push")
+	assert.Contains(t, out.String(), "examples/pre-commit")
+
+	out.Reset()
+	assert.NoError(t, ShowTemplate(&out, "go"))
+	assert.Contains(t, out.String(), "builtin: go:test")
+	assert.Error(t, ShowTemplate(&out, "rust"))
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/vkunssec/husky/internal/tools"
+)
+
+// UninstallOptions are the options for the uninstall command
+type UninstallOptions struct {
+	Purge bool // Remove the .husky directory
+	Quiet bool // Quiet mode
+}
+
+// exported functions
+var (
+	Uninstall = uninstall
+)
+
+// uninstall removes the hooks installed by husky and restores the hooks backed up at install time
+func uninstall(opts UninstallOptions) error {
+	if !opts.Quiet {
+		tools.LogInfo("Uninstalling husky")
+	}
+
+	if !tools.GitExists() {
+		return errors.New("git is not installed")
+	}
+
+	gitHooksDir := tools.GetGitHooksDir(true)
+	huskyHooksDir := tools.GetHuskyHooksDir(true)
+
+	// Stop pointing git to the husky hooks directory
+	if err := unsetHooksPath(huskyHooksDir); err != nil {
+		return err
+	}
+
+	// Remove only the hooks managed by husky
+	entries, err := os.ReadDir(gitHooksDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		hook := filepath.Join(gitHooksDir, entry.Name())
+		if entry.IsDir() || !isManagedHook(hook, filepath.Join(huskyHooksDir, entry.Name())) {
+			continue
+		}
+		if err := unlinkHook(hook); err != nil {
+			return err
+		}
+		if !opts.Quiet {
+			tools.LogInfo("removed %s", hook)
+		}
+	}
+
+	// Restore the backed up hooks that are missing, the newest backup wins
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if err := os.MkdirAll(gitHooksDir, 0755); err != nil {
+			return err
+		}
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		files, err := os.ReadDir(backups[i])
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			target := filepath.Join(gitHooksDir, file.Name())
+			if _, err := os.Stat(target); file.IsDir() || err == nil {
+				continue
+			}
+			if err := copyFile(filepath.Join(backups[i], file.Name()), target); err != nil {
+				return err
+			}
+			if !opts.Quiet {
+				tools.LogInfo("restored %s", target)
+			}
+		}
+	}
+
+	if opts.Purge {
+		if err := os.RemoveAll(tools.GetHuskyDir(true)); err != nil {
+			return err
+		}
+		if !opts.Quiet {
+			tools.LogInfo("removed %s", tools.GetHuskyDir(true))
+		}
+	}
+
+	if !opts.Quiet {
+		tools.LogInfo("Hooks uninstalled")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUninstall(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  InstallOptions
+		purge bool
+	}{
+		{name: "Restore backed up hooks", opts: InstallOptions{Force: true}, purge: false},
+		{name: "Restore and purge .husky", opts: InstallOptions{Force: true}, purge: true},
+		{name: "Restore chained hooks", opts: InstallOptions{Chain: true}, purge: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			tmpDir := t.TempDir()
+			originalWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(originalWd)
+
+			// Repository with hooks from another tool
+			os.MkdirAll(".git/hooks", 0755)
+			os.WriteFile(".git/hooks/pre-commit", []byte("#!/bin/sh\necho foreign\n"), 0755)
+			os.WriteFile(".git/hooks/pre-push.sample", []byte("#!/bin/sh\n"), 0755)
+
+			os.MkdirAll(".husky", 0755)
+			config := NewDefaultConfig()
+			config.Hook("pre-commit").Commands = []*HookCommand{{Run: "go vet ./..."}}
+			config.Hook("commit-msg").Commands = []*HookCommand{{Run: "true"}}
+			assert.NoError(t, SaveConfig(config))
+
+			tt.opts.Quiet = true
+			assert.NoError(t, Install(tt.opts))
+
+			// Installed hooks replaced the foreign one, which was backed up
+			content, _ := os.ReadFile(".git/hooks/pre-commit")
+			assert.True(t, isManagedHook(".git/hooks/pre-commit", ".husky/hooks/pre-commit"), string(content))
+			backups, err := listBackups()
+			assert.NoError(t, err)
+			assert.Len(t, backups, 1)
+
+			// Reinstalling does not back up the hooks managed by husky
+			assert.NoError(t, Install(InstallOptions{Quiet: true}))
+			backups, _ = listBackups()
+			assert.Len(t, backups, 1)
+
+			assert.NoError(t, Uninstall(UninstallOptions{Purge: tt.purge, Quiet: true}))
+
+			content, err = os.ReadFile(".git/hooks/pre-commit")
+			assert.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh\necho foreign\n", string(content))
+
+			_, err = os.Stat(".git/hooks/pre-push.sample")
+			assert.NoError(t, err)
+
+			_, err = os.Stat(".git/hooks/commit-msg")
+			assert.True(t, os.IsNotExist(err), "husky hook must be removed")
+
+			_, err = os.Stat(filepath.Join(".husky", "husky.yaml"))
+			assert.Equal(t, tt.purge, os.IsNotExist(err))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// exported functions
+var (
+	Git         = git
+	StagedFiles = stagedFiles
+	AllFiles    = allFiles
+	PushFiles   = pushFiles
+	RangeFiles  = rangeFiles
+	NewFiles    = newFiles
+	SplitNul    = splitNul
+
+	StagedRemovedFiles = stagedRemovedFiles
+	PushRemovedFiles   = pushRemovedFiles
+	RangeRemovedFiles  = rangeRemovedFiles
+	NewRemovedFiles    = newRemovedFiles
+
+	GetGitConfig   = getGitConfig
+	SetGitConfig   = setGitConfig
+	UnsetGitConfig = unsetGitConfig
+)
+
+// Git runs a git command and returns its output
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+
+	return stdout.String(), nil
+}
+
+// GetGitConfig returns the value of a git config key, empty if it is not set
+func getGitConfig(key string) string {
+	out, err := Git("config", "--get", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// SetGitConfig sets a git config key in the repository config
+func setGitConfig(key, value string) error {
+	_, err := Git("config", key, value)
+	return err
+}
+
+// UnsetGitConfig removes a git config key from the repository config
+func unsetGitConfig(key string) error {
+	_, err := Git("config", "--unset", key)
+	return err
+}
+
+// StagedFiles returns the files added, copied, modified or renamed in the index
+func stagedFiles() ([]string, error) {
+	out, err := Git("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// AllFiles returns the files tracked by git
+func allFiles() ([]string, error) {
+	out, err := Git("ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// PushFiles returns the files changed by the commits not yet pushed to the upstream branch,
+// or to any remote when the branch has no upstream
+func pushFiles() ([]string, error) {
+	out, err := Git("diff", "--name-only", "--diff-filter=ACMR", "-z", "@{upstream}...HEAD")
+	if err == nil {
+		return splitNul(out), nil
+	}
+
+	out, err = Git("log", "--name-only", "--diff-filter=ACMR", "--pretty=format:", "-z", "HEAD", "--not", "--remotes")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// RangeFiles returns the files changed between two commits
+func rangeFiles(from, to string) ([]string, error) {
+	out, err := Git("diff", "--name-only", "--diff-filter=ACMR", "-z", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// NewFiles returns the files changed by the commits reachable from a commit and not from any remote
+func newFiles(to string) ([]string, error) {
+	out, err := Git("log", "--name-only", "--diff-filter=ACMR", "--pretty=format:", "-z", to, "--not", "--remotes")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// StagedRemovedFiles returns the files deleted in the index, and the old paths of the files renamed
+func stagedRemovedFiles() ([]string, error) {
+	out, err := Git("diff", "--cached", "--name-status", "--diff-filter=DR", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return removedPaths(out), nil
+}
+
+// PushRemovedFiles returns the files deleted or renamed away by the commits not yet pushed,
+// like PushFiles
+func pushRemovedFiles() ([]string, error) {
+	out, err := Git("diff", "--name-status", "--diff-filter=DR", "-z", "@{upstream}...HEAD")
+	if err == nil {
+		return removedPaths(out), nil
+	}
+
+	out, err = Git("log", "--name-status", "--diff-filter=DR", "--pretty=format:", "-z", "HEAD", "--not", "--remotes")
+	if err != nil {
+		return nil, err
+	}
+	return removedPaths(out), nil
+}
+
+// RangeRemovedFiles returns the files deleted or renamed away between two commits
+func rangeRemovedFiles(from, to string) ([]string, error) {
+	out, err := Git("diff", "--name-status", "--diff-filter=DR", "-z", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return removedPaths(out), nil
+}
+
+// NewRemovedFiles returns the files deleted or renamed away by the commits reachable from a commit
+// and not from any remote
+func newRemovedFiles(to string) ([]string, error) {
+	out, err := Git("log", "--name-status", "--diff-filter=DR", "--pretty=format:", "-z", to, "--not", "--remotes")
+	if err != nil {
+		return nil, err
+	}
+	return removedPaths(out), nil
+}
+
+// removedPaths returns the deleted paths and the old side of the renames of a NUL separated
+// --name-status output
+func removedPaths(out string) []string {
+	fields := strings.Split(out, "\x00")
+	paths := []string{}
+	for i := 0; i < len(fields); i++ {
+		status := strings.TrimSpace(fields[i])
+		switch {
+		case status == "":
+		case status[0] == 'D' && i+1 < len(fields):
+			paths = append(paths, fields[i+1])
+			i++
+		case (status[0] == 'R' || status[0] == 'C') && i+2 < len(fields):
+			if status[0] == 'R' {
+				paths = append(paths, fields[i+1])
+			}
+			i += 2
+		}
+	}
+	return splitNul(strings.Join(paths, "\x00"))
+}
+
+// splitNul splits NUL separated git output, dropping empty and duplicated entries.
+// The paths are kept as they are, file names may start or end with spaces
+func splitNul(out string) []string {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, file := range strings.Split(out, "\x00") {
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	return files
+}
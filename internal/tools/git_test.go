@@ -0,0 +1,20 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitNul(t *testing.T) {
+	got := SplitNul("a.go\x00 spaced .go\x00a.go\x00\x00")
+	want := []string{"a.go", " spaced .go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitNul() = %q, want %q", got, want)
+	}
+
+	got = removedPaths("D\x00 gone.go\x00R100\x00old.go\x00new.go\x00\x00D\x00b.go\x00")
+	want = []string{" gone.go", "old.go", "b.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("removedPaths() = %q, want %q", got, want)
+	}
+}
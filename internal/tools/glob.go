@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// exported functions
+var (
+	MatchGlob = matchGlob
+)
+
+var (
+	globCache   = map[string]*regexp.Regexp{}
+	globCacheMu sync.Mutex
+)
+
+// MatchGlob reports whether the slash separated file name matches the pattern.
+// Besides the path.Match syntax, "**" matches any number of directories and
+// "{a,b}" matches any of the alternatives. Patterns without a slash are matched
+// against the base name of the file.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// compileGlob converts a glob pattern to a regular expression
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	globCacheMu.Lock()
+	defer globCacheMu.Unlock()
+
+	if re, ok := globCache[pattern]; ok {
+		return re, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	inGroup := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" also matches no directory at all
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case '{':
+			inGroup = true
+			sb.WriteString("(?:")
+		case '}':
+			if inGroup {
+				inGroup = false
+				sb.WriteString(")")
+			} else {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case ',':
+			if inGroup {
+				sb.WriteString("|")
+			} else {
+				sb.WriteString(",")
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, err
+	}
+	globCache[pattern] = re
+	return re, nil
+}
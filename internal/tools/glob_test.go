@@ -0,0 +1,34 @@
+package tools
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"Extension in root", "*.go", "main.go", true},
+		{"Extension in subdirectory", "*.go", "internal/lib/add.go", true},
+		{"Other extension", "*.go", "README.md", false},
+		{"Alternatives", "*.{go,mod}", "go.mod", true},
+		{"Directory wildcard", "internal/**/*.go", "internal/lib/add.go", true},
+		{"Directory wildcard without directories", "internal/**/*.go", "internal/add.go", true},
+		{"Directory wildcard outside directory", "internal/**/*.go", "cmd/add.go", false},
+		{"Single level wildcard", "cmd/*.go", "cmd/sub/add.go", false},
+		{"Leading dot slash", "./cmd/*.go", "cmd/add.go", true},
+		{"Character class", "file[0-9].txt", "file1.txt", true},
+		{"Negated character class", "file[!0-9].txt", "file1.txt", false},
+		{"Question mark", "?.go", "a.go", true},
+		{"Everything below directory", "docs/**", "docs/a/b.md", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.file); got != tt.want {
+				t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+			}
+		})
+	}
+}
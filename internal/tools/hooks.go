@@ -0,0 +1,290 @@
+package tools
+
+// HookSide is where git invokes a hook
+type HookSide string
+
+const (
+	ClientSide HookSide = "client" // invoked in the local repository by commands like commit, merge or push
+	ServerSide HookSide = "server" // invoked in the remote repository receiving a push
+)
+
+// HookInfo describes a hook git knows about
+type HookInfo struct {
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Args        string   `json:"args,omitempty"`  // arguments passed by git, empty if none
+	Stdin       string   `json:"stdin,omitempty"` // format of the input passed by git, empty if none
+	Side        HookSide `json:"side"`
+	CanAbort    bool     `json:"can_abort"`       // whether a non-zero exit status aborts the operation
+	Since       string   `json:"since,omitempty"` // git version introducing the hook, empty if older than 2.0
+}
+
+// hookCatalogEntries lists the hooks documented in githooks(5), grouped by category
+var hookCatalogEntries = []HookInfo{
+	// Commit
+	{
+		Name:        "pre-commit",
+		Category:    "Commit",
+		Description: "Before the commit message is asked, used to check the snapshot being committed",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "prepare-commit-msg",
+		Category:    "Commit",
+		Description: "After the default commit message is prepared, before the editor is started",
+		Args:        "<message file> [<source> [<commit>]]",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "commit-msg",
+		Category:    "Commit",
+		Description: "After the commit message is written, used to validate or normalize it",
+		Args:        "<message file>",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "post-commit",
+		Category:    "Commit",
+		Description: "After a commit is made, used for notifications",
+		Side:        ClientSide,
+	},
+
+	// Merge
+	{
+		Name:        "pre-merge-commit",
+		Category:    "Merge",
+		Description: "After a merge succeeds and before the merge commit is created",
+		Side:        ClientSide,
+		CanAbort:    true,
+		Since:       "2.24",
+	},
+	{
+		Name:        "post-merge",
+		Category:    "Merge",
+		Description: "After a successful merge or pull",
+		Args:        "<squash flag>",
+		Side:        ClientSide,
+	},
+
+	// Rebase and rewrite
+	{
+		Name:        "pre-rebase",
+		Category:    "Rebase",
+		Description: "Before a branch is rebased",
+		Args:        "<upstream> [<branch>]",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "post-rewrite",
+		Category:    "Rebase",
+		Description: "After commits are rewritten by commit --amend or rebase",
+		Args:        "<amend|rebase>",
+		Stdin:       "<old oid> SP <new oid> [SP <extra>] LF",
+		Side:        ClientSide,
+	},
+
+	// Checkout and index
+	{
+		Name:        "post-checkout",
+		Category:    "Checkout",
+		Description: "After a checkout or switch updates the working tree, and after clone",
+		Args:        "<previous HEAD> <new HEAD> <branch flag>",
+		Side:        ClientSide,
+	},
+	{
+		Name:        "post-index-change",
+		Category:    "Checkout",
+		Description: "After the index is written",
+		Args:        "<working tree updated flag> <skip-worktree updated flag>",
+		Side:        ClientSide,
+		Since:       "2.22",
+	},
+	{
+		Name:        "fsmonitor-watchman",
+		Category:    "Checkout",
+		Description: "Queried for the files changed since a point in time when core.fsmonitor is set",
+		Args:        "<version> <token>",
+		Side:        ClientSide,
+		Since:       "2.16",
+	},
+	{
+		Name:        "pre-auto-gc",
+		Category:    "Checkout",
+		Description: "Before git gc --auto repacks the repository",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "reference-transaction",
+		Category:    "Checkout",
+		Description: "When a reference transaction is prepared, committed or aborted",
+		Args:        "<prepared|committed|aborted>",
+		Stdin:       "<old oid> SP <new oid> SP <ref name> LF",
+		Side:        ClientSide,
+		CanAbort:    true,
+		Since:       "2.28",
+	},
+
+	// Push
+	{
+		Name:        "pre-push",
+		Category:    "Push",
+		Description: "Before objects are sent to the remote, after the remote refs are checked",
+		Args:        "<remote name> <remote url>",
+		Stdin:       "<local ref> SP <local oid> SP <remote ref> SP <remote oid> LF",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+
+	// Patch
+	{
+		Name:        "applypatch-msg",
+		Category:    "Patch",
+		Description: "When git am is about to use the commit message of a patch",
+		Args:        "<message file>",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "pre-applypatch",
+		Category:    "Patch",
+		Description: "After git am applies a patch, before the commit is made",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "post-applypatch",
+		Category:    "Patch",
+		Description: "After git am commits a patch",
+		Side:        ClientSide,
+	},
+	{
+		Name:        "sendemail-validate",
+		Category:    "Patch",
+		Description: "Before git send-email sends each email",
+		Args:        "<email file>",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+
+	// Server
+	{
+		Name:        "pre-receive",
+		Category:    "Server",
+		Description: "Before any ref is updated by a push, once for all refs",
+		Stdin:       "<old oid> SP <new oid> SP <ref name> LF",
+		Side:        ServerSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "update",
+		Category:    "Server",
+		Description: "Before each ref is updated by a push",
+		Args:        "<ref name> <old oid> <new oid>",
+		Side:        ServerSide,
+		CanAbort:    true,
+	},
+	{
+		Name:        "proc-receive",
+		Category:    "Server",
+		Description: "Handles the commands of a push matching receive.procReceiveRefs",
+		Stdin:       "pkt-line protocol",
+		Side:        ServerSide,
+		CanAbort:    true,
+		Since:       "2.29",
+	},
+	{
+		Name:        "post-receive",
+		Category:    "Server",
+		Description: "After all refs are updated by a push",
+		Stdin:       "<old oid> SP <new oid> SP <ref name> LF",
+		Side:        ServerSide,
+	},
+	{
+		Name:        "post-update",
+		Category:    "Server",
+		Description: "After all refs are updated by a push, with the names of the updated refs",
+		Args:        "<ref name>...",
+		Side:        ServerSide,
+	},
+	{
+		Name:        "push-to-checkout",
+		Category:    "Server",
+		Description: "When a push updates the checked out branch and receive.denyCurrentBranch is updateInstead",
+		Args:        "<new commit>",
+		Side:        ServerSide,
+		CanAbort:    true,
+		Since:       "2.4",
+	},
+
+	// Perforce
+	{
+		Name:        "p4-changelist",
+		Category:    "Perforce",
+		Description: "After the changelist message of git-p4 submit is edited",
+		Args:        "<changelist file>",
+		Side:        ClientSide,
+		CanAbort:    true,
+		Since:       "2.26",
+	},
+	{
+		Name:        "p4-prepare-changelist",
+		Category:    "Perforce",
+		Description: "Before the changelist message editor of git-p4 submit is started",
+		Args:        "<changelist file>",
+		Side:        ClientSide,
+		CanAbort:    true,
+		Since:       "2.26",
+	},
+	{
+		Name:        "p4-post-changelist",
+		Category:    "Perforce",
+		Description: "After git-p4 submit succeeds",
+		Side:        ClientSide,
+		Since:       "2.26",
+	},
+	{
+		Name:        "p4-pre-submit",
+		Category:    "Perforce",
+		Description: "Before git-p4 submit starts",
+		Side:        ClientSide,
+		CanAbort:    true,
+	},
+}
+
+// HookCatalog returns the hooks git knows about
+func hookCatalog() []HookInfo {
+	catalog := make([]HookInfo, len(hookCatalogEntries))
+	copy(catalog, hookCatalogEntries)
+	return catalog
+}
+
+// LookupHook returns the description of a hook
+func lookupHook(name string) (HookInfo, bool) {
+	for _, hook := range hookCatalogEntries {
+		if hook.Name == name {
+			return hook, true
+		}
+	}
+	return HookInfo{}, false
+}
+
+// ValidHooks returns the names of the hooks git knows about
+func validHooks() []string {
+	names := make([]string, len(hookCatalogEntries))
+	for i, hook := range hookCatalogEntries {
+		names[i] = hook.Name
+	}
+	return names
+}
+
+// IsValidHook checks if the hook is valid
+func isValidHook(hook string) bool {
+	_, ok := lookupHook(hook)
+	return ok
+}
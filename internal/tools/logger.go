@@ -3,6 +3,7 @@ package tools
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type LogLevel int
@@ -20,6 +21,21 @@ func SetLogLevel(level LogLevel) {
 	currentLogLevel = level
 }
 
+// ParseLogLevel converts a log level name (silent, error, info, debug) to a LogLevel
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "silent":
+		return LogLevelSilent, nil
+	case "error":
+		return LogLevelError, nil
+	case "", "info":
+		return LogLevelInfo, nil
+	case "debug":
+		return LogLevelDebug, nil
+	}
+	return LogLevelInfo, fmt.Errorf("invalid log level '%s'", level)
+}
+
 func LogDebug(format string, args ...interface{}) {
 	if currentLogLevel >= LogLevelDebug {
 		fmt.Printf("DEBUG: "+format+"\n", args...)
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotGitRepository is returned when no git repository is found
+var ErrNotGitRepository = errors.New("not a git repository")
+
+// Repository describes the git repository husky operates on
+type Repository struct {
+	WorkTree  string // root of the working tree
+	GitDir    string // git directory of the working tree, .git/worktrees/<name> for linked worktrees
+	CommonDir string // git directory shared by all worktrees, holding the config and the hooks
+}
+
+// HooksDir returns the directory git reads the hooks from, ignoring core.hooksPath
+func (r *Repository) HooksDir() string {
+	return filepath.Join(r.CommonDir, "hooks")
+}
+
+// exported functions
+var (
+	FindRepository = findRepository
+	RelToWorkTree  = relToWorkTree
+)
+
+// FindRepository discovers the repository of the current directory like git does:
+// honoring GIT_DIR, GIT_WORK_TREE and GIT_COMMON_DIR, walking up to the directory
+// containing .git, following "gitdir:" files of worktrees and submodules and the
+// commondir file of linked worktrees
+func findRepository() (*Repository, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &Repository{}
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		repo.GitDir = absPath(cwd, gitDir)
+		if _, err := os.Stat(repo.GitDir); err != nil {
+			return nil, fmt.Errorf("GIT_DIR %s: %w", gitDir, ErrNotGitRepository)
+		}
+		switch workTree := os.Getenv("GIT_WORK_TREE"); {
+		case workTree != "":
+			repo.WorkTree = absPath(cwd, workTree)
+		case filepath.Base(repo.GitDir) == ".git":
+			repo.WorkTree = filepath.Dir(repo.GitDir)
+		default:
+			repo.WorkTree = cwd
+		}
+	} else {
+		for dir := cwd; ; dir = filepath.Dir(dir) {
+			gitDir, err := resolveGitDir(filepath.Join(dir, ".git"))
+			if err == nil {
+				repo.WorkTree = dir
+				repo.GitDir = gitDir
+				break
+			}
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, ErrNotGitRepository
+			}
+		}
+	}
+
+	repo.CommonDir = repo.GitDir
+	if commonDir := os.Getenv("GIT_COMMON_DIR"); commonDir != "" {
+		repo.CommonDir = absPath(cwd, commonDir)
+	} else if content, err := os.ReadFile(filepath.Join(repo.GitDir, "commondir")); err == nil {
+		repo.CommonDir = absPath(repo.GitDir, strings.TrimSpace(string(content)))
+	}
+
+	return repo, nil
+}
+
+// resolveGitDir returns the git directory a .git entry refers to: the entry itself
+// when it is a directory, or the target of the "gitdir:" line when it is a file
+func resolveGitDir(dotGit string) (string, error) {
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid gitfile format: %s", dotGit)
+	}
+
+	gitDir := absPath(filepath.Dir(dotGit), strings.TrimSpace(strings.TrimPrefix(line, "gitdir:")))
+	if _, err := os.Stat(gitDir); err != nil {
+		return "", fmt.Errorf("%s points to %s: %w", dotGit, gitDir, err)
+	}
+	return gitDir, nil
+}
+
+// RelToWorkTree returns the path relative to the root of the working tree,
+// where git runs the hooks, or the path unchanged if it is outside of it
+func relToWorkTree(path string) string {
+	repo, err := FindRepository()
+	if err != nil {
+		return path
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(repo.WorkTree, absPath(cwd, path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// absPath resolves path relative to base
+func absPath(base, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
+
+// relToCwd returns the path relative to the current directory when possible
+func relToCwd(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindRepository(t *testing.T) {
+	// Setup
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+
+	main := filepath.Join(tmpDir, "main")
+	worktree := filepath.Join(tmpDir, "feature")
+	submodule := filepath.Join(main, "libs", "sub")
+
+	// main repository with a linked worktree and a submodule
+	os.MkdirAll(filepath.Join(main, ".git", "hooks"), 0755)
+	os.MkdirAll(filepath.Join(main, ".git", "worktrees", "feature"), 0755)
+	os.MkdirAll(filepath.Join(main, ".git", "modules", "sub"), 0755)
+	os.MkdirAll(filepath.Join(main, "internal", "lib"), 0755)
+	os.WriteFile(filepath.Join(main, ".git", "worktrees", "feature", "commondir"), []byte("../..\n"), 0644)
+
+	os.MkdirAll(filepath.Join(worktree, "cmd"), 0755)
+	os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+filepath.Join(main, ".git", "worktrees", "feature")+"\n"), 0644)
+
+	os.MkdirAll(submodule, 0755)
+	os.WriteFile(filepath.Join(submodule, ".git"), []byte("gitdir: ../../.git/modules/sub\n"), 0644)
+
+	tests := []struct {
+		name          string
+		dir           string
+		env           map[string]string
+		wantWorkTree  string
+		wantGitDir    string
+		wantHooksDir  string
+		wantNotExists bool
+	}{
+		{
+			name:         "Root of the repository",
+			dir:          main,
+			wantWorkTree: main,
+			wantGitDir:   filepath.Join(main, ".git"),
+			wantHooksDir: filepath.Join(main, ".git", "hooks"),
+		},
+		{
+			name:         "Subdirectory",
+			dir:          filepath.Join(main, "internal", "lib"),
+			wantWorkTree: main,
+			wantGitDir:   filepath.Join(main, ".git"),
+			wantHooksDir: filepath.Join(main, ".git", "hooks"),
+		},
+		{
+			name:         "Linked worktree",
+			dir:          filepath.Join(worktree, "cmd"),
+			wantWorkTree: worktree,
+			wantGitDir:   filepath.Join(main, ".git", "worktrees", "feature"),
+			wantHooksDir: filepath.Join(main, ".git", "hooks"),
+		},
+		{
+			name:         "Submodule",
+			dir:          submodule,
+			wantWorkTree: submodule,
+			wantGitDir:   filepath.Join(main, ".git", "modules", "sub"),
+			wantHooksDir: filepath.Join(main, ".git", "modules", "sub", "hooks"),
+		},
+		{
+			name:         "GIT_DIR and GIT_WORK_TREE",
+			dir:          tmpDir,
+			env:          map[string]string{"GIT_DIR": filepath.Join(main, ".git"), "GIT_WORK_TREE": worktree},
+			wantWorkTree: worktree,
+			wantGitDir:   filepath.Join(main, ".git"),
+			wantHooksDir: filepath.Join(main, ".git", "hooks"),
+		},
+		{
+			name:         "GIT_COMMON_DIR",
+			dir:          submodule,
+			env:          map[string]string{"GIT_COMMON_DIR": filepath.Join(main, ".git")},
+			wantWorkTree: submodule,
+			wantGitDir:   filepath.Join(main, ".git", "modules", "sub"),
+			wantHooksDir: filepath.Join(main, ".git", "hooks"),
+		},
+		{
+			name:          "Outside of a repository",
+			dir:           tmpDir,
+			wantNotExists: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_COMMON_DIR"} {
+				t.Setenv(key, tt.env[key])
+				if tt.env[key] == "" {
+					os.Unsetenv(key)
+				}
+			}
+			os.Chdir(tt.dir)
+
+			repo, err := FindRepository()
+			if tt.wantNotExists {
+				if err == nil {
+					t.Errorf("FindRepository() found %s outside of a repository", repo.GitDir)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindRepository() error = %v", err)
+			}
+			if repo.WorkTree != tt.wantWorkTree {
+				t.Errorf("WorkTree = %s, want %s", repo.WorkTree, tt.wantWorkTree)
+			}
+			if repo.GitDir != tt.wantGitDir {
+				t.Errorf("GitDir = %s, want %s", repo.GitDir, tt.wantGitDir)
+			}
+			if repo.HooksDir() != tt.wantHooksDir {
+				t.Errorf("HooksDir() = %s, want %s", repo.HooksDir(), tt.wantHooksDir)
+			}
+		})
+	}
+
+	t.Run("Hooks and husky directories from a subdirectory", func(t *testing.T) {
+		os.Chdir(filepath.Join(worktree, "cmd"))
+		if got := GetGitHooksDir(false); got != filepath.Join(main, ".git", "hooks") {
+			t.Errorf("GetGitHooksDir(false) = %s", got)
+		}
+		if got := GetHuskyHooksDir(true); got != filepath.Join("..", ".husky", "hooks") {
+			t.Errorf("GetHuskyHooksDir(true) = %s", got)
+		}
+		if got := RelToWorkTree(filepath.Join("..", ".husky", "hooks")); got != filepath.Join(".husky", "hooks") {
+			t.Errorf("RelToWorkTree() = %s", got)
+		}
+	})
+}
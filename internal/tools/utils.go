@@ -1,127 +1,84 @@
 package tools
 
 import (
-	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 )
 
 // exported functions
 var (
-	IsValidHook               = isValidHook
-	GitExists                 = gitExists
-	HuskyExists               = huskyExists
-	GetHuskyHooksDir          = getHuskyHooksDir
-	GetGitHooksDir            = getGitHooksDir
-	IsCI                      = isCI
-	ValidHooks                = validHooks
-	ValidHooksWithDescription = validHooksWithDescription
+	IsValidHook      = isValidHook
+	GitExists        = gitExists
+	HuskyExists      = huskyExists
+	GetHuskyDir      = getHuskyDir
+	GetHuskyHooksDir = getHuskyHooksDir
+	GetGitHooksDir   = getGitHooksDir
+	IsCI             = isCI
+	IsTerminal       = isTerminal
+	ValidHooks       = validHooks
+	HookCatalog      = hookCatalog
+	LookupHook       = lookupHook
 )
 
-const (
-	green = "\033[0;32m"
-	nc    = "\033[0m"
-)
-
-// internal functions
-var validHooksWithDescription = []string{
-	// Hooks de commit
-	fmt.Sprintf("%s[pre-commit]%s             Execute before commit", green, nc),
-	fmt.Sprintf("%s[prepare-commit-msg]%s     Execute before commit-msg", green, nc),
-	fmt.Sprintf("%s[commit-msg]%s             Execute before commit", green, nc),
-	fmt.Sprintf("%s[post-commit]%s            Execute after commit", green, nc),
-	fmt.Sprintf("%s[post-commit-msg]%s        Execute after commit-msg\n", green, nc),
-	fmt.Sprintf("%s[pre-merge]%s              Execute before merge", green, nc),
-	fmt.Sprintf("%s[pre-merge-commit]%s       Execute before merge-commit", green, nc),
-	fmt.Sprintf("%s[post-merge]%s             Execute after merge", green, nc),
-	fmt.Sprintf("%s[post-merge-commit]%s      Execute after merge-commit\n", green, nc),
-	fmt.Sprintf("%s[pre-rebase]%s             Execute before rebase", green, nc),
-	fmt.Sprintf("%s[pre-rebase-commit]%s      Execute before rebase-commit", green, nc),
-	fmt.Sprintf("%s[post-rebase]%s            Execute after rebase", green, nc),
-	fmt.Sprintf("%s[post-rebase-commit]%s     Execute after rebase-commit\n", green, nc),
-	fmt.Sprintf("%s[pre-push]%s               Execute before push", green, nc),
-	fmt.Sprintf("%s[update]%s                 Execute after push\n", green, nc),
-	fmt.Sprintf("%s[pre-applypatch]%s         Execute before applypatch", green, nc),
-	fmt.Sprintf("%s[post-applypatch]%s        Execute after applypatch\n", green, nc),
-	fmt.Sprintf("%s[post-checkout]%s          Execute after checkout", green, nc),
+// GitExists checks if the current directory is inside a git repository
+func gitExists() bool {
+	_, err := FindRepository()
+	return err == nil
 }
 
-var validHooks = []string{
-	// Hooks de commit
-	"pre-commit",
-	"prepare-commit-msg",
-	"commit-msg",
-	"post-commit",
-	"post-commit-msg",
-
-	// Hooks de merge
-	"pre-merge",
-	"pre-merge-commit",
-	"post-merge",
-	"post-merge-commit",
-
-	// Hooks de rebase
-	"pre-rebase",
-	"pre-rebase-commit",
-	"post-rebase",
-	"post-rebase-commit",
-
-	// Hooks de push
-	"pre-push",
-	"update",
-
-	// Hooks de patch
-	"pre-applypatch",
-	"post-applypatch",
-
-	// Outros hooks
-	"post-checkout",
+// HuskyExists checks if .husky is installed at the root of the repository
+func huskyExists() bool {
+	_, err := os.Stat(getHuskyDir(true))
+	return err == nil
 }
 
-// IsValidHook checks if the hook is valid
-func isValidHook(hook string) bool {
-	for _, h := range validHooks {
-		if h == hook {
-			return true
-		}
+// workTreeDir returns the root of the working tree, or the current directory outside of a repository
+func workTreeDir() (string, error) {
+	if repo, err := FindRepository(); err == nil {
+		return repo.WorkTree, nil
 	}
-	return false
-}
-
-// GitExists checks if .git is installed
-func gitExists() bool {
-	_, err := os.Stat(".git")
-	return err == nil
+	return os.Getwd()
 }
 
-// HuskyExists checks if .husky is installed
-func huskyExists() bool {
-	_, err := os.Stat(".husky")
-	return err == nil
+// GetHuskyDir returns the path to the husky directory at the root of the repository
+func getHuskyDir(relative bool) string {
+	root, err := workTreeDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(root, ".husky")
+	if relative {
+		return relToCwd(dir)
+	}
+	return dir
 }
 
 // GetHuskyHooksDir returns the path to the husky hooks directory
 func getHuskyHooksDir(relative bool) string {
-	if relative {
-		return path.Join(".husky", "hooks")
-	}
-	cwd, err := os.Getwd()
-	if err != nil {
+	dir := getHuskyDir(relative)
+	if dir == "" {
 		return ""
 	}
-	return path.Join(cwd, ".husky", "hooks")
+	return filepath.Join(dir, "hooks")
 }
 
-// GetGitHooksDir returns the path to the git hooks directory
+// GetGitHooksDir returns the path to the hooks directory of the repository,
+// shared by all its worktrees
 func getGitHooksDir(relative bool) string {
-	if relative {
-		return path.Join(".git", "hooks")
+	dir := ""
+	if repo, err := FindRepository(); err == nil {
+		dir = repo.HooksDir()
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(cwd, ".git", "hooks")
 	}
-	cwd, err := os.Getwd()
-	if err != nil {
-		return ""
+	if relative {
+		return relToCwd(dir)
 	}
-	return path.Join(cwd, ".git", "hooks")
+	return dir
 }
 
 // IsCI checks if the current environment is a CI environment
@@ -143,6 +100,22 @@ func isCI() bool {
 	return false
 }
 
+// IsTerminal checks if the file is an interactive terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// the null device is a character device too, but nobody can type in it
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
 const HuskyGolang = `
   _    _                 _                 _____           _                         
  | |  | |               | |               / ____|         | |                        
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +77,70 @@ func TestIsCI(t *testing.T) {
 		})
 	}
 }
+
+func TestHookCatalog(t *testing.T) {
+	seen := map[string]bool{}
+	for _, hook := range HookCatalog() {
+		if seen[hook.Name] {
+			t.Errorf("hook %s declared twice", hook.Name)
+		}
+		seen[hook.Name] = true
+
+		if hook.Category == "" || hook.Description == "" {
+			t.Errorf("hook %s has no category or description", hook.Name)
+		}
+		if hook.Side != ClientSide && hook.Side != ServerSide {
+			t.Errorf("hook %s has invalid side %q", hook.Name, hook.Side)
+		}
+		if !IsValidHook(hook.Name) {
+			t.Errorf("IsValidHook(%s) = false", hook.Name)
+		}
+	}
+
+	for _, name := range []string{"post-rewrite", "pre-receive", "reference-transaction", "applypatch-msg"} {
+		if !seen[name] {
+			t.Errorf("hook %s missing from the catalog", name)
+		}
+	}
+
+	// names git never invokes
+	for _, name := range []string{"pre-merge", "post-commit-msg", "pre-rebase-commit", "post-rebase"} {
+		if IsValidHook(name) {
+			t.Errorf("IsValidHook(%s) = true", name)
+		}
+	}
+
+	if hook, ok := LookupHook("pre-push"); !ok || !hook.CanAbort || hook.Stdin == "" {
+		t.Errorf("LookupHook(pre-push) = %+v, %v", hook, ok)
+	}
+}
+
+func TestHookCatalogDocumented(t *testing.T) {
+	readme, err := os.ReadFile(filepath.Join("..", "..", "README.md"))
+	if err != nil {
+		t.Skip("README.md not available")
+	}
+	for _, hook := range HookCatalog() {
+		if !strings.Contains(string(readme), "| `"+hook.Name+"` |") {
+			t.Errorf("hook %s is not documented in README.md", hook.Name)
+		}
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip("null device not available")
+	}
+	defer null.Close()
+	if IsTerminal(null) {
+		t.Error("IsTerminal(os.DevNull) = true")
+	}
+
+	r, w, _ := os.Pipe()
+	defer r.Close()
+	defer w.Close()
+	if IsTerminal(r) {
+		t.Error("IsTerminal(pipe) = true")
+	}
+}